@@ -1,5 +1,6 @@
 #!/usr/bin/env bash
-# Build helper/face_detect_mediapipe.py → helper/dist/face_detect (Nuitka onefile).
+# Build helper/face_detect_mediapipe.py → helper/dist/face_detect (Nuitka onefile)
+# and record its SHA-256 in internal/infrastructure/python/face_detect.sha256.
 # Invoke: (cd helper && bash ../build/scripts/build_face_detect.sh) or task build:python:binary.
 
 set -euo pipefail
@@ -31,3 +32,18 @@ rm -rf \
   --output-filename=face_detect \
   --assume-yes-for-downloads \
   face_detect_mediapipe.py
+
+# Record the expected hash in the manifest embedded by the Go app (internal/infrastructure/python).
+# The app refuses to launch a bundled face_detect whose SHA-256 is not listed there.
+MANIFEST="$PROJECT_ROOT/internal/infrastructure/python/face_detect.sha256"
+# <os>/<arch> uses Go's GOOS/GOARCH names; the app only accepts hashes listed for its own platform.
+PLATFORM="$(./.venv/bin/python -c "import platform; m = platform.machine().lower(); print(platform.system().lower() + '/' + {'x86_64': 'amd64', 'aarch64': 'arm64'}.get(m, m))")"
+if command -v sha256sum >/dev/null 2>&1; then
+  HASH="$(sha256sum "$OUT_DIR/face_detect" | awk '{print $1}')"
+else
+  HASH="$(shasum -a 256 "$OUT_DIR/face_detect" | awk '{print $1}')"
+fi
+TMP_MANIFEST="$(mktemp)"
+grep -v "  ${PLATFORM}\$" "$MANIFEST" > "$TMP_MANIFEST" || true
+echo "${HASH}  ${PLATFORM}" >> "$TMP_MANIFEST"
+mv "$TMP_MANIFEST" "$MANIFEST"
@@ -0,0 +1,160 @@
+// Command count は録画したワークアウトを WatchSquatInteractor に流し、スクワットの回数を数える。
+//
+//	go run ./cmd/count [-fps 30] [-speed 0] [-top 0.7 -bottom 0.6] <dir|file.mjpeg|file.y4m>
+//
+// JPEG/PNG 連番のディレクトリ、MJPEG ファイル、Y4M ファイルに対応する。顔検出にはヘルパーを起動して使う。
+// 判定しきい値は保存済みの設定を使い、-top / -bottom で上書きできる。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	dservice "github.com/kikils/desk-squat-tracker/internal/domain/service"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/camera"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/file"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/memory"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/python"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+	"github.com/kikils/desk-squat-tracker/internal/utils"
+)
+
+type options struct {
+	path        string
+	fps         float64
+	speed       float64
+	quality     int
+	topRatio    float64
+	bottomRatio float64
+	verbose     bool
+}
+
+func main() {
+	var opts options
+	flag.Float64Var(&opts.fps, "fps", 30, "フレームレート情報を持たない形式（連番画像・MJPEG）のフレームレート")
+	flag.Float64Var(&opts.speed, "speed", 0, "再生速度の倍率（0 なら待たずに最速で処理する）")
+	flag.IntVar(&opts.quality, "quality", 75, "検出に渡す JPEG の品質")
+	flag.Float64Var(&opts.topRatio, "top", 0, "TopRatio を上書きする（0 なら保存済みの設定）")
+	flag.Float64Var(&opts.bottomRatio, "bottom", 0, "BottomRatio を上書きする（0 なら保存済みの設定）")
+	flag.BoolVar(&opts.verbose, "v", false, "rep を数えた時刻を表示する")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <dir|file.mjpeg|file.y4m>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.path = flag.Arg(0)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, opts)
+	stop()
+	// log.Fatal は defer を実行しないため、ヘルパー（別プロセスグループ）はここで確実に止める
+	python.StopFaceDetectServer()
+	if errors.Is(err, errors.ErrInvalidArgument) {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, opts options) error {
+	settingRepository, err := settingRepositoryWithOverrides(opts.topRatio, opts.bottomRatio)
+	if err != nil {
+		return err
+	}
+
+	if err := python.StartFaceDetectServer(ctx); err != nil {
+		return err
+	}
+	faceRepository, err := python.NewMediaPipeFaceRepository()
+	if err != nil {
+		return err
+	}
+	judgementRepository := memory.NewJudgementRepository()
+	squatJudger := dservice.NewSquatJudger(faceRepository, judgementRepository, settingRepository)
+	inputPort := usecase.NewWatchSquatUsecase(faceRepository, judgementRepository, squatJudger)
+
+	source := camera.NewFileSource(opts.fps, opts.speed, opts.path)
+	stream, err := source.Open(ctx, 0)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var encoder utils.JPEGEncoder
+	var frames, faces, reps int
+	var first time.Time
+	for f := range stream.Frames() {
+		if frames == 0 {
+			first = f.Timestamp
+		}
+		frames++
+		jpegBytes, err := encoder.Encode(utils.Frame{Data: f.Data, Width: f.Width, Height: f.Height}, opts.quality)
+		f.Release()
+		if err != nil || len(jpegBytes) == 0 {
+			continue
+		}
+		out, err := inputPort.Execute(ctx, &usecase.WatchSquatInput{Frame: jpegBytes, Timestamp: f.Timestamp})
+		if err != nil {
+			return err
+		}
+		if out.Face == nil {
+			continue
+		}
+		faces++
+		if out.Judgement.IsRepCompleted {
+			reps++
+			if opts.verbose {
+				fmt.Printf("rep %d at %s\n", reps, f.Timestamp.Sub(first).Round(time.Millisecond))
+			}
+		}
+	}
+	fmt.Printf("frames=%d faces=%d reps=%d\n", frames, faces, reps)
+	// 中断されたときは途中までの結果を表示したうえで失敗として終わる
+	return ctx.Err()
+}
+
+// settingRepositoryWithOverrides は保存済みの設定を読み、指定があればしきい値を上書きしたメモリ上の設定を返す。
+// 上書き後のしきい値が判定に使えない組み合わせなら errors.ErrInvalidArgument を返す。
+func settingRepositoryWithOverrides(topRatio, bottomRatio float64) (repository.SettingRepository, error) {
+	saved, err := file.NewSettingRepository()
+	if err != nil {
+		return nil, err
+	}
+	setting, err := saved.Get()
+	if err != nil {
+		return nil, err
+	}
+	if topRatio != 0 {
+		setting.TopRatio = topRatio
+	}
+	if bottomRatio != 0 {
+		setting.BottomRatio = bottomRatio
+	}
+	if setting.TopRatio <= 0 || setting.TopRatio >= 1 || setting.BottomRatio <= 0 || setting.BottomRatio >= 1 {
+		return nil, errors.ErrInvalidArgument.Errorf("-top and -bottom must be in (0, 1) (top=%g, bottom=%g)", setting.TopRatio, setting.BottomRatio)
+	}
+	if setting.BottomRatio >= setting.TopRatio {
+		return nil, errors.ErrInvalidArgument.Errorf("-bottom must be less than -top (top=%g, bottom=%g)", setting.TopRatio, setting.BottomRatio)
+	}
+	repo := memory.NewSettingRepository()
+	if err := repo.Save(&entity.Setting{TopRatio: setting.TopRatio, BottomRatio: setting.BottomRatio}); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
@@ -0,0 +1,180 @@
+// Command mqttstub は MQTT ブローカーの代わりに、受け取った PUBLISH を表示するローカルの MQTT 3.1.1 サーバー。
+//
+//	go run ./cmd/mqttstub [-addr 127.0.0.1:1883] [-user name -pass secret]
+//
+// 設定画面で MQTT のブローカーを tcp://127.0.0.1:1883 にすると、送られるトピックと Discovery の設定を確かめられる。
+// QoS 0 の PUBLISH・PINGREQ・DISCONNECT だけを扱い、購読はできない。接続が切れたときは Will を表示する。
+// アプリを止めずにスタブを止めて起動し直すと、つなぎ直して retained の値を送り直すことも確かめられる。
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:1883", "待ち受けるアドレス")
+	user := flag.String("user", "", "指定するとこのユーザー名とパスワードの接続だけを受け付ける")
+	pass := flag.String("pass", "", "-user のパスワード")
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("listening on tcp://%s", *addr)
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go serve(nc, *user, *pass)
+	}
+}
+
+// will は CONNECT で受け取った、切断を検知したときに送るメッセージ。
+type will struct {
+	topic, payload string
+	retain         bool
+}
+
+func serve(nc net.Conn, user, pass string) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	remote := nc.RemoteAddr()
+
+	typ, _, body, err := readPacket(r)
+	if err != nil || typ != 1 {
+		log.Printf("%s: expected CONNECT: %v", remote, err)
+		return
+	}
+	clientID, w, username, password, keepAlive, err := parseConnect(body)
+	if err != nil {
+		log.Printf("%s: %v", remote, err)
+		return
+	}
+	code := byte(0)
+	if user != "" && (username != user || password != pass) {
+		code = 4 // bad user name or password
+	}
+	nc.Write([]byte{2 << 4, 2, 0, code})
+	if code != 0 {
+		log.Printf("%s: refused %s (bad credentials)", remote, clientID)
+		return
+	}
+	log.Printf("%s: CONNECT client=%s user=%q keepalive=%ds", remote, clientID, username, keepAlive)
+
+	for {
+		if keepAlive > 0 {
+			nc.SetReadDeadline(time.Now().Add(time.Duration(keepAlive) * 1500 * time.Millisecond))
+		}
+		typ, flags, body, err := readPacket(r)
+		if err != nil {
+			if w != nil {
+				fmt.Printf("%s WILL %s retain=%v %s\n", time.Now().Format(time.TimeOnly), w.topic, w.retain, w.payload)
+			}
+			if !errors.Is(err, io.EOF) {
+				log.Printf("%s: %v", remote, err)
+			}
+			return
+		}
+		switch typ {
+		case 3:
+			if flags&0x06 != 0 {
+				log.Printf("%s: QoS > 0 is not supported", remote)
+				return
+			}
+			topic, rest, err := readString(body)
+			if err != nil {
+				log.Printf("%s: %v", remote, err)
+				return
+			}
+			fmt.Printf("%s PUBLISH %s retain=%v %s\n", time.Now().Format(time.TimeOnly), topic, flags&0x01 != 0, rest)
+		case 12:
+			nc.Write([]byte{13 << 4, 0})
+		case 14:
+			log.Printf("%s: DISCONNECT", remote)
+			return
+		default:
+			log.Printf("%s: ignoring packet type %d", remote, typ)
+		}
+	}
+}
+
+func parseConnect(body []byte) (clientID string, w *will, username, password string, keepAlive int, err error) {
+	proto, rest, err := readString(body)
+	if err != nil || proto != "MQTT" || len(rest) < 4 || rest[0] != 4 {
+		return "", nil, "", "", 0, fmt.Errorf("unsupported protocol %q", proto)
+	}
+	flags := rest[1]
+	keepAlive = int(binary.BigEndian.Uint16(rest[2:4]))
+	rest = rest[4:]
+	if clientID, rest, err = readString(rest); err != nil {
+		return
+	}
+	if flags&0x04 != 0 {
+		w = &will{retain: flags&0x20 != 0}
+		if w.topic, rest, err = readString(rest); err != nil {
+			return
+		}
+		if w.payload, rest, err = readString(rest); err != nil {
+			return
+		}
+	}
+	if flags&0x80 != 0 {
+		if username, rest, err = readString(rest); err != nil {
+			return
+		}
+	}
+	if flags&0x40 != 0 {
+		if password, _, err = readString(rest); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func readPacket(r *bufio.Reader) (typ, flags byte, body []byte, err error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	length, shift := 0, 0
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, 0, nil, fmt.Errorf("malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		length |= int(digit&0x7f) << shift
+		if digit&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+	body = make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, 0, nil, err
+	}
+	return header >> 4, header & 0x0f, body, nil
+}
+
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, fmt.Errorf("truncated string")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, fmt.Errorf("truncated string")
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
@@ -0,0 +1,120 @@
+// Command replay は記録したキャプチャセッションを WatchSquatInteractor に流し直し、判定結果を比較する。
+//
+//	go run ./cmd/replay [-top 0.7] [-bottom 0.6] [-detect] [-v] session-YYYYMMDD-HHMMSS.jsonl
+//
+// 既定では記録済みの顔検出結果を使うため、顔検出ヘルパーなしで決定的に再生できる。
+// -detect を付けると記録した JPEG を顔検出ヘルパーで検出し直す（プライバシーモードの記録では使えない）。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	dservice "github.com/kikils/desk-squat-tracker/internal/domain/service"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/memory"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/python"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/session"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+	"golang.org/x/xerrors"
+)
+
+type options struct {
+	path        string
+	topRatio    float64
+	bottomRatio float64
+	detect      bool
+	verbose     bool
+}
+
+func main() {
+	var opts options
+	flag.Float64Var(&opts.topRatio, "top", 0, "TopRatio を上書きする（0 なら記録時の設定）")
+	flag.Float64Var(&opts.bottomRatio, "bottom", 0, "BottomRatio を上書きする（0 なら記録時の設定）")
+	flag.BoolVar(&opts.detect, "detect", false, "記録した JPEG を顔検出ヘルパーで検出し直す")
+	flag.BoolVar(&opts.verbose, "v", false, "状態が変わったフレームを表示する")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <session.jsonl>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.path = flag.Arg(0)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, opts)
+	stop()
+	// log.Fatal は defer を実行しないため、-detect で起動したヘルパーはここで確実に止める
+	python.StopFaceDetectServer()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, opts options) error {
+	s, err := session.Load(opts.path)
+	if err != nil {
+		return err
+	}
+
+	setting := s.Header.Setting.Setting()
+	if opts.topRatio > 0 {
+		setting.TopRatio = opts.topRatio
+	}
+	if opts.bottomRatio > 0 {
+		setting.BottomRatio = opts.bottomRatio
+	}
+	settingRepository := memory.NewSettingRepository()
+	if err := settingRepository.Save(setting); err != nil {
+		return err
+	}
+
+	var faceRepository repository.FaceRepository
+	if opts.detect {
+		if s.Header.Privacy {
+			return xerrors.New("replay: -detect needs JPEG frames, but the session was recorded in privacy mode")
+		}
+		if err := python.StartFaceDetectServer(ctx); err != nil {
+			return err
+		}
+		if faceRepository, err = python.NewMediaPipeFaceRepository(); err != nil {
+			return err
+		}
+	} else {
+		faceRepository = session.NewReplayFaceRepository(s)
+	}
+
+	judgementRepository := memory.NewJudgementRepository()
+	squatJudger := dservice.NewSquatJudger(faceRepository, judgementRepository, settingRepository)
+	inputPort := usecase.NewWatchSquatUsecase(faceRepository, judgementRepository, squatJudger)
+
+	res, err := session.Replay(ctx, s, inputPort)
+	if err != nil {
+		return err
+	}
+
+	if opts.verbose {
+		prev := entity.DetectStateUnknown
+		for _, j := range res.Judgements {
+			if j.State == prev && !j.IsRepCompleted {
+				continue
+			}
+			prev = j.State
+			fmt.Printf("%s state=%s rep=%t\n", j.Timestamp.Format(time.RFC3339Nano), j.State, j.IsRepCompleted)
+		}
+	}
+	fmt.Printf("setting: top=%.3f bottom=%.3f\n", setting.TopRatio, setting.BottomRatio)
+	fmt.Printf("frames=%d faces=%d reps=%d recorded_reps=%d mismatches=%d\n",
+		res.Frames, res.Faces, res.Reps, res.RecordedReps, res.Mismatches)
+	return nil
+}
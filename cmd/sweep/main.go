@@ -0,0 +1,159 @@
+// Command sweep は記録済みの顔トレースと正解 rep 時刻を使い、SquatJudger のパラメータをグリッド探索する。
+//
+//	go run ./cmd/sweep [-top 0.55:0.85:0.05] [-bottom 0.40:0.75:0.05] [-tolerance 1.5s] session.jsonl...
+//
+// 正解ラベルは各セッションの横に置いた <session>.labels（1 行 1 つの RFC3339 時刻）から読み込む。
+// 組み合わせごとに precision / recall / F1 / 回数誤差を表示し、最も良いパラメータを推奨する。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/session"
+)
+
+type trace struct {
+	session *session.Session
+	labels  []time.Time
+}
+
+type result struct {
+	setting entity.Setting
+	metrics session.Metrics
+}
+
+func main() {
+	topRange := flag.String("top", "0.55:0.85:0.05", "TopRatio の範囲 (start:end:step)")
+	bottomRange := flag.String("bottom", "0.40:0.75:0.05", "BottomRatio の範囲 (start:end:step)")
+	tolerance := flag.Duration("tolerance", 1500*time.Millisecond, "判定 rep と正解 rep を同一とみなす時刻差")
+	top := flag.Int("n", 20, "表示する上位件数（0 なら全件）")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <session.jsonl>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	tops, err := parseRange(*topRange)
+	if err != nil {
+		log.Fatalf("sweep: -top: %v", err)
+	}
+	bottoms, err := parseRange(*bottomRange)
+	if err != nil {
+		log.Fatalf("sweep: -bottom: %v", err)
+	}
+
+	var traces []trace
+	for _, path := range flag.Args() {
+		s, err := session.Load(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		labels, err := session.LoadLabels(strings.TrimSuffix(path, ".jsonl") + ".labels")
+		if err != nil {
+			log.Fatal(err)
+		}
+		traces = append(traces, trace{session: s, labels: labels})
+	}
+
+	ctx := context.Background()
+	var results []result
+	for _, t := range tops {
+		for _, b := range bottoms {
+			// UpdateSettingInteractor と同じく bottom < top の組み合わせのみ有効
+			if b >= t {
+				continue
+			}
+			setting := entity.Setting{TopRatio: t, BottomRatio: b}
+			var total session.Metrics
+			for _, tr := range traces {
+				m, err := session.Evaluate(ctx, tr.session, tr.labels, &setting, *tolerance)
+				if err != nil {
+					log.Fatal(err)
+				}
+				total = total.Add(m)
+			}
+			results = append(results, result{setting: setting, metrics: total})
+		}
+	}
+	if len(results) == 0 {
+		log.Fatal("sweep: no valid parameter combination (bottom must be less than top)")
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return better(results[i], results[j]) })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "top\tbottom\tprecision\trecall\tf1\tcount_err\ttp\tfp\tfn\t")
+	for i, r := range results {
+		if *top > 0 && i >= *top {
+			break
+		}
+		m := r.metrics
+		fmt.Fprintf(w, "%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%d\t%d\t%d\t%d\t\n",
+			r.setting.TopRatio, r.setting.BottomRatio, m.Precision(), m.Recall(), m.F1(), m.CountError,
+			m.TruePositives, m.FalsePositives, m.FalseNegatives)
+	}
+	_ = w.Flush()
+
+	best := results[0]
+	fmt.Printf("\nrecommended: top=%.3f bottom=%.3f (f1=%.3f count_err=%d over %d session(s))\n",
+		best.setting.TopRatio, best.setting.BottomRatio, best.metrics.F1(), best.metrics.CountError, len(traces))
+}
+
+// better は F1 が高い順、同点なら回数誤差が小さい順、さらに同点なら top と bottom の間隔が広い（揺れに強い）順。
+func better(a, b result) bool {
+	if fa, fb := a.metrics.F1(), b.metrics.F1(); math.Abs(fa-fb) > 1e-9 {
+		return fa > fb
+	}
+	if a.metrics.CountError != b.metrics.CountError {
+		return a.metrics.CountError < b.metrics.CountError
+	}
+	return a.setting.TopRatio-a.setting.BottomRatio > b.setting.TopRatio-b.setting.BottomRatio
+}
+
+// parseRange は "start:end:step" を値の一覧に展開する。単一の値も受け付ける。
+func parseRange(s string) ([]float64, error) {
+	parts := strings.Split(s, ":")
+	nums := make([]float64, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		nums[i] = v
+	}
+	switch len(nums) {
+	case 1:
+		return nums, nil
+	case 3:
+		start, end, step := nums[0], nums[1], nums[2]
+		if step <= 0 || end < start {
+			return nil, fmt.Errorf("invalid range %q", s)
+		}
+		var out []float64
+		for i := 0; ; i++ {
+			v := start + float64(i)*step
+			if v > end+1e-9 {
+				break
+			}
+			out = append(out, math.Round(v*1000)/1000)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("invalid range %q (want start:end:step)", s)
+	}
+}
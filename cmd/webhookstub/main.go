@@ -0,0 +1,44 @@
+// Command webhookstub は Webhook の送信先の代わりに、受け取ったリクエストを表示するローカル HTTP サーバー。
+//
+//	go run ./cmd/webhookstub [-addr 127.0.0.1:9876] [-fail 0]
+//
+// 設定画面で Webhook の URL を http://127.0.0.1:9876/ にすると、送信テストや rep の通知を確かめられる。
+// -fail N を付けると最初の N 件に 500 を返すので、再送と再起動をまたいだキューを確かめられる。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9876", "待ち受けるアドレス")
+	fail := flag.Int64("fail", 0, "最初にこの件数だけ 500 を返す")
+	flag.Parse()
+
+	var received atomic.Int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		n := received.Add(1)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status := http.StatusOK
+		if n <= *fail {
+			status = http.StatusInternalServerError
+		}
+		fmt.Printf("#%d %s %s %s event=%s delivery=%s content-type=%s -> %d\n%s\n",
+			n, time.Now().Format(time.TimeOnly), r.Method, r.URL.Path,
+			r.Header.Get("X-Webhook-Event"), r.Header.Get("X-Webhook-Delivery"), r.Header.Get("Content-Type"),
+			status, body)
+		w.WriteHeader(status)
+	})
+	log.Printf("listening on http://%s/", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
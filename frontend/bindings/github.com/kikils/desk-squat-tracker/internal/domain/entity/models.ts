@@ -0,0 +1,176 @@
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import { Create as $Create } from "@wailsio/runtime";
+
+/**
+ * APISetting はローカル HTTP API の設定。Enabled のときだけ 127.0.0.1 で待ち受ける。
+ */
+export class APISetting {
+    "Enabled": boolean;
+    "Port": number;
+
+    /**
+     * Authorization: Bearer で渡すトークン。有効にしたときに生成する
+     */
+    "Token": string;
+
+    /** Creates a new APISetting instance. */
+    constructor($$source: Partial<APISetting> = {}) {
+        if (!("Enabled" in $$source)) {
+            this["Enabled"] = false;
+        }
+        if (!("Port" in $$source)) {
+            this["Port"] = 0;
+        }
+        if (!("Token" in $$source)) {
+            this["Token"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new APISetting instance from a string or object.
+     */
+    static createFrom($$source: any = {}): APISetting {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new APISetting($$parsedSource as Partial<APISetting>);
+    }
+}
+
+/**
+ * Region はフレームに対する正規化した矩形（各値 0〜1）。幅か高さが 0 ならフレーム全体を表す。
+ */
+export class Region {
+    "X": number;
+    "Y": number;
+    "Width": number;
+    "Height": number;
+
+    /** Creates a new Region instance. */
+    constructor($$source: Partial<Region> = {}) {
+        if (!("X" in $$source)) {
+            this["X"] = 0;
+        }
+        if (!("Y" in $$source)) {
+            this["Y"] = 0;
+        }
+        if (!("Width" in $$source)) {
+            this["Width"] = 0;
+        }
+        if (!("Height" in $$source)) {
+            this["Height"] = 0;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Region instance from a string or object.
+     */
+    static createFrom($$source: any = {}): Region {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Region($$parsedSource as Partial<Region>);
+    }
+}
+
+/**
+ * Webhook は WorkoutEvent を POST する送信先。
+ */
+export class Webhook {
+    "ID": string;
+    "Name": string;
+    "URL": string;
+    "Enabled": boolean;
+
+    /**
+     * Events は送る出来事の種類。空ならすべて送る
+     */
+    "Events": WorkoutEventType[];
+
+    /**
+     * Template は本文の Go text/template（.Type / .Timestamp / .TodayCount / .SetReps / .Goal と json 関数が使える）。
+     * 空なら WorkoutEvent を JSON にしたものを送る
+     */
+    "Template": string;
+
+    /**
+     * 空なら application/json
+     */
+    "ContentType": string;
+
+    /** Creates a new Webhook instance. */
+    constructor($$source: Partial<Webhook> = {}) {
+        if (!("ID" in $$source)) {
+            this["ID"] = "";
+        }
+        if (!("Name" in $$source)) {
+            this["Name"] = "";
+        }
+        if (!("URL" in $$source)) {
+            this["URL"] = "";
+        }
+        if (!("Enabled" in $$source)) {
+            this["Enabled"] = false;
+        }
+        if (!("Events" in $$source)) {
+            this["Events"] = [];
+        }
+        if (!("Template" in $$source)) {
+            this["Template"] = "";
+        }
+        if (!("ContentType" in $$source)) {
+            this["ContentType"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Webhook instance from a string or object.
+     */
+    static createFrom($$source: any = {}): Webhook {
+        const $$createField4_0 = $$createType0;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("Events" in $$parsedSource) {
+            $$parsedSource["Events"] = $$createField4_0($$parsedSource["Events"]);
+        }
+        return new Webhook($$parsedSource as Partial<Webhook>);
+    }
+}
+
+/**
+ * WorkoutEventType は rep の判定から導く、通知の対象になる出来事の種類。
+ */
+export enum WorkoutEventType {
+    /**
+     * The Go zero value for the underlying type of the enum.
+     */
+    $zero = "",
+
+    /**
+     * rep を 1 回数えた
+     */
+    WorkoutEventRep = "rep",
+
+    /**
+     * 休憩を挟んで 1 セットが終わった
+     */
+    WorkoutEventSet = "set",
+
+    /**
+     * 今日の回数が 1 日の目標に達した
+     */
+    WorkoutEventGoal = "goal",
+
+    /**
+     * 設定画面からの送信テスト
+     */
+    WorkoutEventTest = "test",
+};
+
+// Private type creation functions
+const $$createType0 = $Create.Array($Create.Any);
@@ -5,6 +5,20 @@
 // @ts-ignore: Unused imports
 import { Call as $Call, CancellablePromise as $CancellablePromise, Create as $Create } from "@wailsio/runtime";
 
+/**
+ * GetHelperError は顔検出ヘルパーの起動エラーを返す。正常に起動していれば空文字。
+ */
+export function GetHelperError(): $CancellablePromise<string> {
+    return $Call.ByID(2355484809);
+}
+
+/**
+ * IsHeadless はサーバーとして起動しているかを返す。フロントはこのときアプリの終了ボタンを出さない。
+ */
+export function IsHeadless(): $CancellablePromise<boolean> {
+    return $Call.ByID(2194416408);
+}
+
 /**
  * Quit はアプリ全体を終了します（トレイ常駐も含めてプロセスが終了します）。
  * バインディングの応答が WebView に返る前に同期で Quit するとデッドロックするため、
@@ -10,19 +10,52 @@ import { Call as $Call, CancellablePromise as $CancellablePromise, Create as $Cr
 import * as $models from "./models.js";
 
 /**
- * ListCameras は利用可能なカメラ一覧を返す。
+ * CaptureDeviceID はキャプチャ中のデバイスの ID を返す。キャプチャしていなければ空文字。
+ */
+export function CaptureDeviceID(): $CancellablePromise<string> {
+    return $Call.ByID(162066478);
+}
+
+/**
+ * Diagnostics はキャプチャ中の検出頻度と動きの大きさを返す。キャプチャしていなければ Capturing が false。
+ */
+export function Diagnostics(): $CancellablePromise<$models.CameraDiagnostics> {
+    return $Call.ByID(756299531).then(($result: any) => {
+        return $$createType0($result);
+    });
+}
+
+/**
+ * ListCameras は利用可能なカメラ一覧を返す。末尾には常に WebView のカメラ（BrowserCameraID）を含める。
  */
 export function ListCameras(): $CancellablePromise<$models.CameraDevice[]> {
     return $Call.ByID(3981206455).then(($result: any) => {
-        return $$createType1($result);
+        return $$createType2($result);
     });
 }
 
 /**
- * StartCapture は指定したデバイスインデックスでキャプチャを開始する。macOS では AVFoundation で選択。
+ * PreviewURL はカメラのプレビューを取得する URL を返す。after=<X-Preview-Seq> を付けると次のフレームまで待つ。
+ * 配信していなければ空文字。
+ */
+export function PreviewURL(): $CancellablePromise<string> {
+    return $Call.ByID(1472065012);
+}
+
+/**
+ * PushFrame はフロントエンドが getUserMedia で取得した JPEG フレームを顔検出・判定に流す。
+ * 前のフレームを処理中であれば検出せずに捨て、false を返す（フロントエンドは次のフレームを送ればよい）。
+ */
+export function PushFrame(jpegBytes: string): $CancellablePromise<boolean> {
+    return $Call.ByID(2080768058, jpegBytes);
+}
+
+/**
+ * StartCapture は指定した ID のデバイスから Source でキャプチャを開始し、選んだカメラとして保存する。
+ * フレームが途絶えたり切断されたりした場合は、同じデバイスを探してバックオフしながら開き直す。
  */
-export function StartCapture(deviceIndex: number): $CancellablePromise<void> {
-    return $Call.ByID(3412175283, deviceIndex);
+export function StartCapture(deviceID: string): $CancellablePromise<void> {
+    return $Call.ByID(3412175283, deviceID);
 }
 
 export function StopCapture(): $CancellablePromise<void> {
@@ -30,5 +63,6 @@ export function StopCapture(): $CancellablePromise<void> {
 }
 
 // Private type creation functions
-const $$createType0 = $models.CameraDevice.createFrom;
-const $$createType1 = $Create.Array($$createType0);
+const $$createType0 = $models.CameraDiagnostics.createFrom;
+const $$createType1 = $models.CameraDevice.createFrom;
+const $$createType2 = $Create.Array($$createType1);
@@ -6,14 +6,19 @@ import * as CameraService from "./cameraservice.js";
 import * as GreetService from "./greetservice.js";
 import * as SettingsService from "./settingsservice.js";
 import * as StatsService from "./statsservice.js";
+import * as WebhookService from "./webhookservice.js";
 export {
     AppService,
     CameraService,
     GreetService,
     SettingsService,
-    StatsService
+    StatsService,
+    WebhookService
 };
 
 export {
-    CameraDevice
+    CameraDevice,
+    CameraDiagnostics,
+    CameraStatus,
+    LightingWarning
 } from "./models.js";
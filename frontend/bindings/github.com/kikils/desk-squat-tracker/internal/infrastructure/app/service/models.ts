@@ -9,17 +9,25 @@ import { Create as $Create } from "@wailsio/runtime";
  * CameraDevice はフロントに渡すカメラ情報。
  */
 export class CameraDevice {
-    "Index": number;
+    "ID": string;
     "Name": string;
 
+    /**
+     * true ならフロントエンドで取得し PushFrame で送るカメラ
+     */
+    "Browser": boolean;
+
     /** Creates a new CameraDevice instance. */
     constructor($$source: Partial<CameraDevice> = {}) {
-        if (!("Index" in $$source)) {
-            this["Index"] = 0;
+        if (!("ID" in $$source)) {
+            this["ID"] = "";
         }
         if (!("Name" in $$source)) {
             this["Name"] = "";
         }
+        if (!("Browser" in $$source)) {
+            this["Browser"] = false;
+        }
 
         Object.assign(this, $$source);
     }
@@ -32,3 +40,161 @@ export class CameraDevice {
         return new CameraDevice($$parsedSource as Partial<CameraDevice>);
     }
 }
+
+/**
+ * CameraDiagnostics は検出頻度の診断情報。
+ */
+export class CameraDiagnostics {
+    "capturing": boolean;
+    "mode": string;
+
+    /**
+     * 0 なら上限なし
+     */
+    "targetFps": number;
+
+    /**
+     * 直近で実際に検出した頻度
+     */
+    "detectFps": number;
+    "idleFps": number;
+    "activeFps": number;
+
+    /**
+     * 直近のフレームで変化した画素の割合
+     */
+    "changed": number;
+
+    /**
+     * 直近の上下の動き（フレームの高さ / 秒）
+     */
+    "verticalSpeed": number;
+    "judgeState": string;
+
+    /** Creates a new CameraDiagnostics instance. */
+    constructor($$source: Partial<CameraDiagnostics> = {}) {
+        if (!("capturing" in $$source)) {
+            this["capturing"] = false;
+        }
+        if (!("mode" in $$source)) {
+            this["mode"] = "";
+        }
+        if (!("targetFps" in $$source)) {
+            this["targetFps"] = 0;
+        }
+        if (!("detectFps" in $$source)) {
+            this["detectFps"] = 0;
+        }
+        if (!("idleFps" in $$source)) {
+            this["idleFps"] = 0;
+        }
+        if (!("activeFps" in $$source)) {
+            this["activeFps"] = 0;
+        }
+        if (!("changed" in $$source)) {
+            this["changed"] = 0;
+        }
+        if (!("verticalSpeed" in $$source)) {
+            this["verticalSpeed"] = 0;
+        }
+        if (!("judgeState" in $$source)) {
+            this["judgeState"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new CameraDiagnostics instance from a string or object.
+     */
+    static createFrom($$source: any = {}): CameraDiagnostics {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new CameraDiagnostics($$parsedSource as Partial<CameraDiagnostics>);
+    }
+}
+
+/**
+ * CameraStatus はフロントに通知するキャプチャの状態。
+ */
+export class CameraStatus {
+    "state": string;
+    "deviceId": string;
+    "deviceName": string;
+
+    /**
+     * 再接続の試行回数
+     */
+    "attempt"?: number;
+
+    /**
+     * 直前の再接続の失敗理由
+     */
+    "error"?: string;
+
+    /** Creates a new CameraStatus instance. */
+    constructor($$source: Partial<CameraStatus> = {}) {
+        if (!("state" in $$source)) {
+            this["state"] = "";
+        }
+        if (!("deviceId" in $$source)) {
+            this["deviceId"] = "";
+        }
+        if (!("deviceName" in $$source)) {
+            this["deviceName"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new CameraStatus instance from a string or object.
+     */
+    static createFrom($$source: any = {}): CameraStatus {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new CameraStatus($$parsedSource as Partial<CameraStatus>);
+    }
+}
+
+/**
+ * LightingWarning はフロントに通知する撮影条件。Condition が "ok" なら警告の解除。
+ */
+export class LightingWarning {
+    "condition": string;
+    "mean": number;
+    "centerMean": number;
+    "surroundMean": number;
+
+    /**
+     * 検出前に明るさを補正しているか
+     */
+    "corrected": boolean;
+
+    /** Creates a new LightingWarning instance. */
+    constructor($$source: Partial<LightingWarning> = {}) {
+        if (!("condition" in $$source)) {
+            this["condition"] = "";
+        }
+        if (!("mean" in $$source)) {
+            this["mean"] = 0;
+        }
+        if (!("centerMean" in $$source)) {
+            this["centerMean"] = 0;
+        }
+        if (!("surroundMean" in $$source)) {
+            this["surroundMean"] = 0;
+        }
+        if (!("corrected" in $$source)) {
+            this["corrected"] = false;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new LightingWarning instance from a string or object.
+     */
+    static createFrom($$source: any = {}): LightingWarning {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new LightingWarning($$parsedSource as Partial<LightingWarning>);
+    }
+}
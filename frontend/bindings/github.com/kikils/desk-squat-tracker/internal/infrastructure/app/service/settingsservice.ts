@@ -5,6 +5,9 @@
 // @ts-ignore: Unused imports
 import { Call as $Call, CancellablePromise as $CancellablePromise, Create as $Create } from "@wailsio/runtime";
 
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as entity$0 from "../../../domain/entity/models.js";
 // eslint-disable-next-line @typescript-eslint/ban-ts-comment
 // @ts-ignore: Unused imports
 import * as usecase$0 from "../../../usecase/models.js";
@@ -15,6 +18,47 @@ export function GetSetting(): $CancellablePromise<usecase$0.GetSettingOutput | n
     });
 }
 
+/**
+ * UpdateAPISetting はローカル HTTP API の有効・無効とポートを保存して反映し、保存後の設定（トークンを含む）を返す。
+ * regenerateToken なら新しいトークンを発行し、以前のトークンを使えなくする。
+ */
+export function UpdateAPISetting(enabled: boolean, port: number, regenerateToken: boolean): $CancellablePromise<entity$0.APISetting | null> {
+    return $Call.ByID(3754326238, enabled, port, regenerateToken).then(($result: any) => {
+        return $$createType3($result);
+    });
+}
+
+/**
+ * UpdateCaptureSetting は取り込みの解像度・フレームレート・回転・左右反転を保存し、キャプチャ中のストリームにも反映する。
+ */
+export function UpdateCaptureSetting($in: usecase$0.UpdateCaptureSettingInput | null): $CancellablePromise<void> {
+    return $Call.ByID(2222734096, $in);
+}
+
+/**
+ * UpdateDailyGoal は 1 日の目標回数を保存する。0 なら目標なし。
+ */
+export function UpdateDailyGoal(goal: number): $CancellablePromise<void> {
+    return $Call.ByID(769976060, goal);
+}
+
+/**
+ * UpdateMQTTSetting は MQTT の設定を保存して反映する。ブローカーにつながるまで待たずに返す。
+ * Password が nil なら保存済みのパスワードを使う。返す設定にパスワードは含めない。
+ */
+export function UpdateMQTTSetting($in: usecase$0.UpdateMQTTSettingInput | null): $CancellablePromise<usecase$0.MQTTSettingOutput | null> {
+    return $Call.ByID(1362451188, $in).then(($result: any) => {
+        return $$createType5($result);
+    });
+}
+
+/**
+ * UpdateROI は顔検出の対象にする範囲（フレームに対する比率）を保存する。width か height が 0 ならフレーム全体に戻す。
+ */
+export function UpdateROI(x: number, y: number, width: number, height: number): $CancellablePromise<void> {
+    return $Call.ByID(3948133984, x, y, width, height);
+}
+
 export function UpdateSetting(topRatio: number, bottomRatio: number): $CancellablePromise<void> {
     return $Call.ByID(3724466834, topRatio, bottomRatio);
 }
@@ -22,3 +66,7 @@ export function UpdateSetting(topRatio: number, bottomRatio: number): $Cancellab
 // Private type creation functions
 const $$createType0 = usecase$0.GetSettingOutput.createFrom;
 const $$createType1 = $Create.Nullable($$createType0);
+const $$createType2 = entity$0.APISetting.createFrom;
+const $$createType3 = $Create.Nullable($$createType2);
+const $$createType4 = usecase$0.MQTTSettingOutput.createFrom;
+const $$createType5 = $Create.Nullable($$createType4);
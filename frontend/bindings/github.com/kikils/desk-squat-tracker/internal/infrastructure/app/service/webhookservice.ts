@@ -0,0 +1,46 @@
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+/**
+ * WebhookService は rep・セット・目標の達成を知らせる Webhook の設定と送信テスト。
+ * @module
+ */
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import { Call as $Call, CancellablePromise as $CancellablePromise, Create as $Create } from "@wailsio/runtime";
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as entity$0 from "../../../domain/entity/models.js";
+
+export function DeleteWebhook(id: string): $CancellablePromise<void> {
+    return $Call.ByID(3338914719, id);
+}
+
+export function ListWebhooks(): $CancellablePromise<entity$0.Webhook[]> {
+    return $Call.ByID(628714551).then(($result: any) => {
+        return $$createType1($result);
+    });
+}
+
+/**
+ * SaveWebhook は ID が空なら Webhook を追加し、あればその Webhook を置き換える。URL とテンプレートはここで検証する。
+ */
+export function SaveWebhook(webhook: entity$0.Webhook | null): $CancellablePromise<entity$0.Webhook | null> {
+    return $Call.ByID(2338360573, webhook).then(($result: any) => {
+        return $$createType2($result);
+    });
+}
+
+/**
+ * SendTestWebhook は test の出来事をすぐに送り、送れなければそのエラーを返す。
+ */
+export function SendTestWebhook(id: string): $CancellablePromise<void> {
+    return $Call.ByID(3892801440, id);
+}
+
+// Private type creation functions
+const $$createType0 = entity$0.Webhook.createFrom;
+const $$createType1 = $Create.Array($$createType0);
+const $$createType2 = $Create.Nullable($$createType0);
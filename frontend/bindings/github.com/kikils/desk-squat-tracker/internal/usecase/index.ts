@@ -3,5 +3,8 @@
 
 export {
     GetSettingOutput,
-    GetStatsOutput
+    GetStatsOutput,
+    MQTTSettingOutput,
+    UpdateCaptureSettingInput,
+    UpdateMQTTSettingInput
 } from "./models.js";
@@ -5,9 +5,23 @@
 // @ts-ignore: Unused imports
 import { Create as $Create } from "@wailsio/runtime";
 
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as entity$0 from "../domain/entity/models.js";
+
 export class GetSettingOutput {
     "TopRatio": number;
     "BottomRatio": number;
+    "CameraID": string;
+    "CaptureWidth": number;
+    "CaptureHeight": number;
+    "MaxFPS": number;
+    "Rotation": number;
+    "Mirror": boolean;
+    "ROI": entity$0.Region;
+    "API": entity$0.APISetting;
+    "DailyGoal": number;
+    "MQTT": MQTTSettingOutput;
 
     /** Creates a new GetSettingOutput instance. */
     constructor($$source: Partial<GetSettingOutput> = {}) {
@@ -17,6 +31,36 @@ export class GetSettingOutput {
         if (!("BottomRatio" in $$source)) {
             this["BottomRatio"] = 0;
         }
+        if (!("CameraID" in $$source)) {
+            this["CameraID"] = "";
+        }
+        if (!("CaptureWidth" in $$source)) {
+            this["CaptureWidth"] = 0;
+        }
+        if (!("CaptureHeight" in $$source)) {
+            this["CaptureHeight"] = 0;
+        }
+        if (!("MaxFPS" in $$source)) {
+            this["MaxFPS"] = 0;
+        }
+        if (!("Rotation" in $$source)) {
+            this["Rotation"] = 0;
+        }
+        if (!("Mirror" in $$source)) {
+            this["Mirror"] = false;
+        }
+        if (!("ROI" in $$source)) {
+            this["ROI"] = (new entity$0.Region());
+        }
+        if (!("API" in $$source)) {
+            this["API"] = (new entity$0.APISetting());
+        }
+        if (!("DailyGoal" in $$source)) {
+            this["DailyGoal"] = 0;
+        }
+        if (!("MQTT" in $$source)) {
+            this["MQTT"] = (new MQTTSettingOutput());
+        }
 
         Object.assign(this, $$source);
     }
@@ -25,7 +69,19 @@ export class GetSettingOutput {
      * Creates a new GetSettingOutput instance from a string or object.
      */
     static createFrom($$source: any = {}): GetSettingOutput {
+        const $$createField8_0 = $$createType0;
+        const $$createField9_0 = $$createType1;
+        const $$createField11_0 = $$createType2;
         let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("ROI" in $$parsedSource) {
+            $$parsedSource["ROI"] = $$createField8_0($$parsedSource["ROI"]);
+        }
+        if ("API" in $$parsedSource) {
+            $$parsedSource["API"] = $$createField9_0($$parsedSource["API"]);
+        }
+        if ("MQTT" in $$parsedSource) {
+            $$parsedSource["MQTT"] = $$createField11_0($$parsedSource["MQTT"]);
+        }
         return new GetSettingOutput($$parsedSource as Partial<GetSettingOutput>);
     }
 }
@@ -50,3 +106,145 @@ export class GetStatsOutput {
         return new GetStatsOutput($$parsedSource as Partial<GetStatsOutput>);
     }
 }
+
+/**
+ * MQTTSettingOutput は MQTT の設定。パスワードは返さず、設定してあるかだけを返す。
+ */
+export class MQTTSettingOutput {
+    "Enabled": boolean;
+    "Broker": string;
+    "Username": string;
+    "HasPassword": boolean;
+    "TopicPrefix": string;
+    "DiscoveryPrefix": string;
+
+    /** Creates a new MQTTSettingOutput instance. */
+    constructor($$source: Partial<MQTTSettingOutput> = {}) {
+        if (!("Enabled" in $$source)) {
+            this["Enabled"] = false;
+        }
+        if (!("Broker" in $$source)) {
+            this["Broker"] = "";
+        }
+        if (!("Username" in $$source)) {
+            this["Username"] = "";
+        }
+        if (!("HasPassword" in $$source)) {
+            this["HasPassword"] = false;
+        }
+        if (!("TopicPrefix" in $$source)) {
+            this["TopicPrefix"] = "";
+        }
+        if (!("DiscoveryPrefix" in $$source)) {
+            this["DiscoveryPrefix"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new MQTTSettingOutput instance from a string or object.
+     */
+    static createFrom($$source: any = {}): MQTTSettingOutput {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new MQTTSettingOutput($$parsedSource as Partial<MQTTSettingOutput>);
+    }
+}
+
+export class UpdateCaptureSettingInput {
+    /**
+     * 0 ならソースの解像度のまま（Height も 0 にする）
+     */
+    "Width": number;
+    "Height": number;
+
+    /**
+     * 0 なら制限しない。それ以外は 1 以上
+     */
+    "MaxFPS": number;
+
+    /**
+     * 0 / 90 / 180 / 270
+     */
+    "Rotation": number;
+    "Mirror": boolean;
+
+    /** Creates a new UpdateCaptureSettingInput instance. */
+    constructor($$source: Partial<UpdateCaptureSettingInput> = {}) {
+        if (!("Width" in $$source)) {
+            this["Width"] = 0;
+        }
+        if (!("Height" in $$source)) {
+            this["Height"] = 0;
+        }
+        if (!("MaxFPS" in $$source)) {
+            this["MaxFPS"] = 0;
+        }
+        if (!("Rotation" in $$source)) {
+            this["Rotation"] = 0;
+        }
+        if (!("Mirror" in $$source)) {
+            this["Mirror"] = false;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new UpdateCaptureSettingInput instance from a string or object.
+     */
+    static createFrom($$source: any = {}): UpdateCaptureSettingInput {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new UpdateCaptureSettingInput($$parsedSource as Partial<UpdateCaptureSettingInput>);
+    }
+}
+
+export class UpdateMQTTSettingInput {
+    "Enabled": boolean;
+    "Broker": string;
+    "Username": string;
+
+    /**
+     * Password は nil なら保存済みのパスワードをそのまま使う（GetSetting はパスワードを返さないため）。空文字列なら消す
+     */
+    "Password": string | null;
+    "TopicPrefix": string;
+    "DiscoveryPrefix": string;
+
+    /** Creates a new UpdateMQTTSettingInput instance. */
+    constructor($$source: Partial<UpdateMQTTSettingInput> = {}) {
+        if (!("Enabled" in $$source)) {
+            this["Enabled"] = false;
+        }
+        if (!("Broker" in $$source)) {
+            this["Broker"] = "";
+        }
+        if (!("Username" in $$source)) {
+            this["Username"] = "";
+        }
+        if (!("Password" in $$source)) {
+            this["Password"] = null;
+        }
+        if (!("TopicPrefix" in $$source)) {
+            this["TopicPrefix"] = "";
+        }
+        if (!("DiscoveryPrefix" in $$source)) {
+            this["DiscoveryPrefix"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new UpdateMQTTSettingInput instance from a string or object.
+     */
+    static createFrom($$source: any = {}): UpdateMQTTSettingInput {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new UpdateMQTTSettingInput($$parsedSource as Partial<UpdateMQTTSettingInput>);
+    }
+}
+
+// Private type creation functions
+const $$createType0 = entity$0.Region.createFrom;
+const $$createType1 = entity$0.APISetting.createFrom;
+const $$createType2 = MQTTSettingOutput.createFrom;
@@ -9,15 +9,22 @@ import { Create as $Create } from "@wailsio/runtime";
 // eslint-disable-next-line @typescript-eslint/ban-ts-comment
 // @ts-ignore: Unused imports
 import * as app$0 from "../../../../kikils/desk-squat-tracker/internal/infrastructure/app/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as service$0 from "../../../../kikils/desk-squat-tracker/internal/infrastructure/app/service/models.js";
 
 function configure() {
     Object.freeze(Object.assign($Create.Events, {
-        "face": $$createType1,
+        "cameraStatus": $$createType0,
+        "face": $$createType2,
+        "lightingWarning": $$createType3,
     }));
 }
 
 // Private type creation functions
-const $$createType0 = app$0.FaceViewModel.createFrom;
-const $$createType1 = $Create.Nullable($$createType0);
+const $$createType0 = service$0.CameraStatus.createFrom;
+const $$createType1 = app$0.FaceViewModel.createFrom;
+const $$createType2 = $Create.Nullable($$createType1);
+const $$createType3 = service$0.LightingWarning.createFrom;
 
 configure();
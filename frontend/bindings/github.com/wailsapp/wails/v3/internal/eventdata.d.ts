@@ -8,12 +8,16 @@ import type { Events } from "@wailsio/runtime";
 // eslint-disable-next-line @typescript-eslint/ban-ts-comment
 // @ts-ignore: Unused imports
 import type * as app$0 from "../../../../kikils/desk-squat-tracker/internal/infrastructure/app/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import type * as service$0 from "../../../../kikils/desk-squat-tracker/internal/infrastructure/app/service/models.js";
 
 declare module "@wailsio/runtime" {
     namespace Events {
         interface CustomEvents {
-            "cameraPreview": string;
+            "cameraStatus": service$0.CameraStatus;
             "face": app$0.FaceViewModel | null;
+            "lightingWarning": service$0.LightingWarning;
             "squat": number;
             "time": string;
         }
@@ -1,7 +1,13 @@
 import { useState, useEffect, useCallback, useRef } from 'react'
 import { Events, WML } from "@wailsio/runtime";
-import { AppService, CameraService, SettingsService, StatsService, type CameraDevice } from "../bindings/github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service";
+import { AppService, CameraService, SettingsService, StatsService, type CameraDevice, type CameraDiagnostics } from "../bindings/github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service";
+import { UpdateMQTTSettingInput, type UpdateCaptureSettingInput } from "../bindings/github.com/kikils/desk-squat-tracker/internal/usecase";
+import { WorkoutEventType } from "../bindings/github.com/kikils/desk-squat-tracker/internal/domain/entity";
 import { useCameraStream } from "./hooks/useCameraStream";
+import { useCameraPreview } from "./hooks/useCameraPreview";
+import { useApiSetting } from "./hooks/useApiSetting";
+import { useWebhooks } from "./hooks/useWebhooks";
+import { useMqttSetting } from "./hooks/useMqttSetting";
 
 export interface FaceDetectedPayload {
   x: number;
@@ -17,6 +23,41 @@ export interface FaceDetectedPayload {
 
 type Page = 'summary' | 'camera';
 
+type CaptureSetting = Pick<UpdateCaptureSettingInput, 'Width' | 'Height' | 'MaxFPS' | 'Rotation' | 'Mirror'>;
+
+const DEFAULT_CAPTURE_SETTING: CaptureSetting = { Width: 352, Height: 288, MaxFPS: 0, Rotation: 0, Mirror: false };
+
+const RESOLUTION_OPTIONS: { label: string; width: number; height: number }[] = [
+  { label: 'ソースのまま', width: 0, height: 0 },
+  { label: '320×240', width: 320, height: 240 },
+  { label: '352×288', width: 352, height: 288 },
+  { label: '640×480', width: 640, height: 480 },
+  { label: '1280×720', width: 1280, height: 720 },
+];
+
+type ROI = { X: number; Y: number; Width: number; Height: number };
+
+const FULL_ROI: ROI = { X: 0, Y: 0, Width: 0, Height: 0 };
+const MIN_ROI_SIZE = 0.1;
+
+const FPS_OPTIONS = [0, 5, 10, 15, 30];
+const ROTATION_OPTIONS = [0, 90, 180, 270];
+
+// CameraService.Diagnostics のポーリング間隔と、検出頻度のモードの表示名
+const DIAGNOSTICS_INTERVAL_MS = 2000;
+const DETECT_MODE_LABELS: Record<string, string> = {
+  full: '全フレーム',
+  idle: '待機中',
+  active: '動作中',
+};
+
+// lightingWarning の condition ごとの案内
+const LIGHTING_MESSAGES: Record<string, string> = {
+  too_dark: '部屋が暗すぎます。照明をつけてください',
+  backlit: '逆光です。窓や照明を背にしないようにしてください',
+  overexposed: '明るすぎます。カメラに強い光が当たっていないか確認してください',
+};
+
 const PAGE_LABELS: Record<Page, string> = {
   summary: '成績',
   camera: 'カメラ設定',
@@ -26,14 +67,22 @@ function App() {
   const [page, setPage] = useState<Page>('summary');
   const [todayCount, setTodayCount] = useState<number | null>(null);
   const [faceData, setFaceData] = useState<FaceDetectedPayload | null>(null);
-  const [previewDataUrl, setPreviewDataUrl] = useState<string | null>(null);
   const [topRatio, setTopRatio] = useState<number>(0.7);
   const [bottomRatio, setBottomRatio] = useState<number>(0.6);
   const [settingLoaded, setSettingLoaded] = useState(false);
   const [draggingLine, setDraggingLine] = useState<'top' | 'bottom' | null>(null);
   const [cameras, setCameras] = useState<CameraDevice[]>([]);
-  const [selectedCameraIndex, setSelectedCameraIndex] = useState(0);
+  const [selectedCameraId, setSelectedCameraId] = useState('');
+  const [captureSetting, setCaptureSetting] = useState<CaptureSetting>(DEFAULT_CAPTURE_SETTING);
+  const [roi, setRoi] = useState<ROI>(FULL_ROI);
+  const [roiEditing, setRoiEditing] = useState(false);
+  const [roiDraft, setRoiDraft] = useState<ROI | null>(null);
+  const roiStartRef = useRef<{ x: number; y: number } | null>(null);
+  const roiOverlayRef = useRef<HTMLDivElement>(null);
   const [quitConfirmOpen, setQuitConfirmOpen] = useState(false);
+  const [helperError, setHelperError] = useState<string | null>(null);
+  const [headless, setHeadless] = useState(false);
+  const [diagnostics, setDiagnostics] = useState<CameraDiagnostics | null>(null);
   const overlayRef = useRef<HTMLDivElement>(null);
   const ratiosRef = useRef({ topRatio: 0.7, bottomRatio: 0.6 });
   const lastRatiosRef = useRef({ topRatio: 0.7, bottomRatio: 0.6 });
@@ -41,7 +90,27 @@ function App() {
   ratiosRef.current = { topRatio, bottomRatio };
   if (draggingLine === null) lastRatiosRef.current = { topRatio, bottomRatio };
 
-  const { isActive, error, start, stop } = useCameraStream();
+  const { isActive, error, status: cameraStatus, lighting, browserStream, start, stop } = useCameraStream();
+  const browserVideoRef = useRef<HTMLVideoElement>(null);
+  // プレビューはカメラページで Go 側のキャプチャを見ている間だけ取得する
+  const previewUrl = useCameraPreview(page === 'camera' && isActive && !browserStream);
+  const api = useApiSetting(page === 'summary');
+  const [apiPortDraft, setApiPortDraft] = useState('');
+  useEffect(() => {
+    if (api.setting) setApiPortDraft(String(api.setting.Port));
+  }, [api.setting]);
+  const hooks = useWebhooks(page === 'summary');
+  const [goalDraft, setGoalDraft] = useState('');
+  const [webhookDraft, setWebhookDraft] = useState({ url: '', template: '', events: [] as WorkoutEventType[] });
+  useEffect(() => {
+    if (hooks.dailyGoal !== null) setGoalDraft(String(hooks.dailyGoal));
+  }, [hooks.dailyGoal]);
+  const mqtt = useMqttSetting(page === 'summary');
+  const [mqttDraft, setMqttDraft] = useState<UpdateMQTTSettingInput | null>(null);
+  useEffect(() => {
+    // パスワードは読み出せないので、入力しなければ（null なら）保存済みのものを使う
+    if (mqtt.setting) setMqttDraft(new UpdateMQTTSettingInput({ ...mqtt.setting, Password: null }));
+  }, [mqtt.setting]);
 
   const clampRatio = useCallback((value: number) => Math.max(0, Math.min(1, value)), []);
 
@@ -142,6 +211,14 @@ function App() {
         if (out) {
           setTopRatio(out.TopRatio);
           setBottomRatio(out.BottomRatio);
+          setCaptureSetting({
+            Width: out.CaptureWidth,
+            Height: out.CaptureHeight,
+            MaxFPS: out.MaxFPS,
+            Rotation: out.Rotation,
+            Mirror: out.Mirror,
+          });
+          if (out.ROI) setRoi(out.ROI);
         }
         setSettingLoaded(true);
       })
@@ -157,20 +234,108 @@ function App() {
     }
   }, [page, settingLoaded, fetchSetting]);
 
+  const updateCaptureSetting = useCallback((patch: Partial<CaptureSetting>) => {
+    const next = { ...captureSetting, ...patch };
+    setCaptureSetting(next);
+    SettingsService.UpdateCaptureSetting(next).catch((err) =>
+      console.warn('UpdateCaptureSetting error:', err)
+    );
+  }, [captureSetting]);
+
+  const saveRoi = useCallback((next: ROI) => {
+    setRoi(next);
+    SettingsService.UpdateROI(next.X, next.Y, next.Width, next.Height).catch((err) =>
+      console.warn('UpdateROI error:', err)
+    );
+  }, []);
+
+  const getPointFromEvent = useCallback((e: React.PointerEvent) => {
+    const el = roiOverlayRef.current;
+    if (!el) return { x: 0, y: 0 };
+    const rect = el.getBoundingClientRect();
+    return {
+      x: clampRatio((e.clientX - rect.left) / rect.width),
+      y: clampRatio((e.clientY - rect.top) / rect.height),
+    };
+  }, [clampRatio]);
+
+  // プレビュー上をドラッグして検出範囲（ROI）を描く
+  const handleRoiPointerDown = useCallback((e: React.PointerEvent) => {
+    e.preventDefault();
+    (e.target as HTMLElement).setPointerCapture?.(e.pointerId);
+    roiStartRef.current = getPointFromEvent(e);
+    setRoiDraft(null);
+  }, [getPointFromEvent]);
+
+  const handleRoiPointerMove = useCallback((e: React.PointerEvent) => {
+    const start = roiStartRef.current;
+    if (!start) return;
+    const p = getPointFromEvent(e);
+    setRoiDraft({
+      X: Math.min(start.x, p.x),
+      Y: Math.min(start.y, p.y),
+      Width: Math.abs(p.x - start.x),
+      Height: Math.abs(p.y - start.y),
+    });
+  }, [getPointFromEvent]);
+
+  const handleRoiPointerUp = useCallback(() => {
+    roiStartRef.current = null;
+    if (roiDraft && roiDraft.Width >= MIN_ROI_SIZE && roiDraft.Height >= MIN_ROI_SIZE) {
+      saveRoi(roiDraft);
+      setRoiEditing(false);
+    }
+    setRoiDraft(null);
+  }, [roiDraft, saveRoi]);
+
+  // ヘッダーのスイッチは成績ページからも使うため、起動時にもカメラ一覧を読み込む
   useEffect(() => {
-    if (page === 'camera') {
-      CameraService.ListCameras()
-        .then((list) => {
-          const devs = list ?? [];
-          setCameras(devs);
-          if (devs.length > 0) {
-            setSelectedCameraIndex((prev) => (prev < devs.length ? prev : 0));
+    CameraService.ListCameras()
+      .then(async (list) => {
+        const devs = list ?? [];
+        setCameras(devs);
+        if (devs.length === 0) return;
+        // キャプチャ中のカメラ → 前回選んだカメラ → 先頭の順で選択する
+        const [capturing, setting] = await Promise.all([
+          CameraService.CaptureDeviceID().catch(() => ''),
+          SettingsService.GetSetting().catch(() => null),
+        ]);
+        setSelectedCameraId((prev) => {
+          for (const id of [capturing, prev, setting?.CameraID ?? '']) {
+            if (id && devs.some((cam) => cam.ID === id)) return id;
           }
-        })
-        .catch(() => setCameras([]));
-    }
+          return devs[0].ID;
+        });
+      })
+      .catch(() => setCameras([]));
   }, [page]);
 
+  // 検出頻度はカメラページでキャプチャしている間だけ取得する
+  useEffect(() => {
+    if (page !== 'camera' || !isActive) {
+      setDiagnostics(null);
+      return;
+    }
+    const load = () => {
+      CameraService.Diagnostics()
+        .then((d) => setDiagnostics(d.capturing ? d : null))
+        .catch((err) => console.warn('Diagnostics error:', err));
+    };
+    load();
+    const timer = setInterval(load, DIAGNOSTICS_INTERVAL_MS);
+    return () => clearInterval(timer);
+  }, [page, isActive]);
+
+  useEffect(() => {
+    AppService.GetHelperError()
+      .then((msg) => setHelperError(msg || null))
+      .catch((err) => console.warn('GetHelperError error:', err));
+    // サーバーとして動いているときはブラウザからアプリを終了させない
+    AppService.IsHeadless()
+      .then(setHeadless)
+      .catch((err) => console.warn('IsHeadless error:', err));
+  }, []);
+
   useEffect(() => {
     Events.On('face', (ev: { data?: FaceDetectedPayload | null }) => {
       const payload = ev.data;
@@ -181,15 +346,12 @@ function App() {
     Events.On('squat', () => {
       fetchTodayStats();
     });
-    Events.On('cameraPreview', (ev: { data?: string }) => {
-      if (typeof ev.data === 'string') setPreviewDataUrl(ev.data);
-    });
     WML.Reload();
   }, [fetchTodayStats]);
 
   useEffect(() => {
-    if (!isActive) setPreviewDataUrl(null);
-  }, [isActive]);
+    if (browserVideoRef.current) browserVideoRef.current.srcObject = browserStream;
+  }, [browserStream, isActive, page]);
 
   useEffect(() => {
     if (page === 'camera') {
@@ -206,8 +368,13 @@ function App() {
   };
 
   const handleCameraSwitch = () => {
-    if (isActive) stop();
-    else start(selectedCameraIndex);
+    if (isActive) {
+      stop();
+      return;
+    }
+    const selected = cameras.find((cam) => cam.ID === selectedCameraId) ?? cameras[0];
+    if (!selected) return;
+    start(selected.ID, selected.Browser);
   };
 
   const handleSwitchKey = (e: React.KeyboardEvent) => {
@@ -268,6 +435,11 @@ function App() {
         </nav>
 
         <main id="main" tabIndex={-1}>
+          {helperError && (
+            <p className="error-msg" role="alert">
+              顔検出ヘルパーを起動できませんでした: {helperError}
+            </p>
+          )}
           {page === 'summary' && (
             <section
               className="card card--summary reveal-summary"
@@ -288,6 +460,239 @@ function App() {
               </p>
             </section>
           )}
+          {page === 'summary' && api.setting && (
+            <details className="api-settings">
+              <summary>連携 API（ダッシュボード・ボット向け）</summary>
+              <div className="api-settings__row">
+                <label className="camera-capture-mirror">
+                  <input
+                    type="checkbox"
+                    checked={api.setting.Enabled}
+                    disabled={api.saving}
+                    onChange={(e) => api.update({ enabled: e.target.checked, port: Number(apiPortDraft) })}
+                  />
+                  127.0.0.1 で待ち受ける
+                </label>
+                <input
+                  type="number"
+                  className="camera-select api-settings__port"
+                  min={1024}
+                  max={65535}
+                  value={apiPortDraft}
+                  disabled={api.saving}
+                  onChange={(e) => setApiPortDraft(e.target.value)}
+                  onBlur={() => {
+                    if (api.setting && Number(apiPortDraft) !== api.setting.Port) {
+                      api.update({ enabled: api.setting.Enabled, port: Number(apiPortDraft) });
+                    }
+                  }}
+                  aria-label="API のポート"
+                />
+              </div>
+              {api.setting.Enabled && api.setting.Token && (
+                <div className="api-settings__row">
+                  <code className="api-settings__token" aria-label="API のトークン">
+                    {api.setting.Token}
+                  </code>
+                  <button
+                    type="button"
+                    className="camera-roi-btn"
+                    disabled={api.saving}
+                    onClick={() => api.update({ enabled: true, port: api.setting!.Port, regenerateToken: true })}
+                  >
+                    再発行
+                  </button>
+                </div>
+              )}
+              {api.error && (
+                <p className="error-msg" role="alert">
+                  {api.error}
+                </p>
+              )}
+            </details>
+          )}
+          {page === 'summary' && hooks.dailyGoal !== null && (
+            <details className="api-settings">
+              <summary>Webhook 通知（rep・セット・目標）</summary>
+              <div className="api-settings__row">
+                <label htmlFor="daily-goal">1 日の目標</label>
+                <input
+                  id="daily-goal"
+                  type="number"
+                  className="camera-select api-settings__port"
+                  min={0}
+                  max={10000}
+                  value={goalDraft}
+                  disabled={hooks.saving}
+                  onChange={(e) => setGoalDraft(e.target.value)}
+                  onBlur={() => {
+                    if (Number(goalDraft) !== hooks.dailyGoal) hooks.updateGoal(Number(goalDraft));
+                  }}
+                />
+                回（0 なら目標なし）
+              </div>
+              {hooks.webhooks.map((webhook) => (
+                <div key={webhook.ID} className="api-settings__row">
+                  <label className="camera-capture-mirror">
+                    <input
+                      type="checkbox"
+                      checked={webhook.Enabled}
+                      disabled={hooks.saving}
+                      onChange={(e) => hooks.save({ ...webhook, Enabled: e.target.checked })}
+                    />
+                    有効
+                  </label>
+                  <span className="api-settings__token" title={webhook.Template || undefined}>
+                    {webhook.URL}（{webhook.Events?.length ? webhook.Events.join('・') : 'すべて'}）
+                  </span>
+                  <button type="button" className="camera-roi-btn" disabled={hooks.saving} onClick={() => hooks.test(webhook.ID)}>
+                    テスト
+                  </button>
+                  <button type="button" className="camera-roi-btn" disabled={hooks.saving} onClick={() => hooks.remove(webhook.ID)}>
+                    削除
+                  </button>
+                </div>
+              ))}
+              <form
+                className="webhook-form"
+                onSubmit={(e) => {
+                  e.preventDefault();
+                  hooks.save({
+                    URL: webhookDraft.url.trim(),
+                    Template: webhookDraft.template,
+                    Events: webhookDraft.events,
+                    Enabled: true,
+                  });
+                  setWebhookDraft({ url: '', template: '', events: [] });
+                }}
+              >
+                <div className="api-settings__row">
+                  <input
+                    type="url"
+                    className="camera-select api-settings__token"
+                    placeholder="https://example.com/hook"
+                    value={webhookDraft.url}
+                    required
+                    onChange={(e) => setWebhookDraft({ ...webhookDraft, url: e.target.value })}
+                    aria-label="Webhook の URL"
+                  />
+                  <button type="submit" className="camera-roi-btn" disabled={hooks.saving}>
+                    追加
+                  </button>
+                </div>
+                <div className="api-settings__row">
+                  {[WorkoutEventType.WorkoutEventRep, WorkoutEventType.WorkoutEventSet, WorkoutEventType.WorkoutEventGoal].map((type) => (
+                    <label key={type} className="camera-capture-mirror">
+                      <input
+                        type="checkbox"
+                        checked={webhookDraft.events.includes(type)}
+                        onChange={(e) =>
+                          setWebhookDraft({
+                            ...webhookDraft,
+                            events: e.target.checked
+                              ? [...webhookDraft.events, type]
+                              : webhookDraft.events.filter((t) => t !== type),
+                          })
+                        }
+                      />
+                      {type}
+                    </label>
+                  ))}
+                  （選ばなければすべて）
+                </div>
+                <textarea
+                  className="webhook-form__template"
+                  placeholder={'本文のテンプレート（空なら JSON）例: {"text": {{json (printf "今日 %d 回" .TodayCount)}}}'}
+                  value={webhookDraft.template}
+                  onChange={(e) => setWebhookDraft({ ...webhookDraft, template: e.target.value })}
+                  aria-label="本文のテンプレート"
+                />
+              </form>
+              {hooks.notice && <p role="status">{hooks.notice}</p>}
+              {hooks.error && (
+                <p className="error-msg" role="alert">
+                  {hooks.error}
+                </p>
+              )}
+            </details>
+          )}
+          {page === 'summary' && mqttDraft && (
+            <details className="api-settings">
+              <summary>MQTT（Home Assistant 向け）</summary>
+              <form
+                onSubmit={(e) => {
+                  e.preventDefault();
+                  mqtt.update(mqttDraft);
+                }}
+              >
+                <div className="api-settings__row">
+                  <label className="camera-capture-mirror">
+                    <input
+                      type="checkbox"
+                      checked={mqttDraft.Enabled}
+                      onChange={(e) => setMqttDraft(new UpdateMQTTSettingInput({ ...mqttDraft, Enabled: e.target.checked }))}
+                    />
+                    ブローカーに送る
+                  </label>
+                  <input
+                    type="text"
+                    className="camera-select api-settings__token"
+                    placeholder="tcp://homeassistant.local:1883"
+                    value={mqttDraft.Broker}
+                    onChange={(e) => setMqttDraft(new UpdateMQTTSettingInput({ ...mqttDraft, Broker: e.target.value }))}
+                    aria-label="ブローカーの URL"
+                  />
+                </div>
+                <div className="api-settings__row">
+                  <input
+                    type="text"
+                    className="camera-select api-settings__token"
+                    placeholder="ユーザー名"
+                    value={mqttDraft.Username}
+                    autoComplete="off"
+                    onChange={(e) => setMqttDraft(new UpdateMQTTSettingInput({ ...mqttDraft, Username: e.target.value }))}
+                    aria-label="ユーザー名"
+                  />
+                  <input
+                    type="password"
+                    className="camera-select api-settings__token"
+                    placeholder={mqtt.setting?.HasPassword ? 'パスワード（保存済み）' : 'パスワード'}
+                    value={mqttDraft.Password ?? ''}
+                    autoComplete="off"
+                    onChange={(e) => setMqttDraft(new UpdateMQTTSettingInput({ ...mqttDraft, Password: e.target.value }))}
+                    aria-label="パスワード"
+                  />
+                </div>
+                <div className="api-settings__row">
+                  <input
+                    type="text"
+                    className="camera-select api-settings__token"
+                    value={mqttDraft.TopicPrefix}
+                    onChange={(e) => setMqttDraft(new UpdateMQTTSettingInput({ ...mqttDraft, TopicPrefix: e.target.value }))}
+                    aria-label="トピックの接頭辞"
+                    title="トピックの接頭辞"
+                  />
+                  <input
+                    type="text"
+                    className="camera-select api-settings__token"
+                    placeholder="Discovery なし"
+                    value={mqttDraft.DiscoveryPrefix}
+                    onChange={(e) => setMqttDraft(new UpdateMQTTSettingInput({ ...mqttDraft, DiscoveryPrefix: e.target.value }))}
+                    aria-label="Home Assistant の Discovery の接頭辞"
+                    title="Home Assistant の Discovery の接頭辞"
+                  />
+                  <button type="submit" className="camera-roi-btn" disabled={mqtt.saving}>
+                    保存
+                  </button>
+                </div>
+              </form>
+              {mqtt.error && (
+                <p className="error-msg" role="alert">
+                  {mqtt.error}
+                </p>
+              )}
+            </details>
+          )}
 
           {page === 'camera' && (
             <section
@@ -303,22 +708,86 @@ function App() {
                 <select
                   id="camera-select"
                   className="camera-select"
-                  value={selectedCameraIndex}
-                  onChange={(e) => setSelectedCameraIndex(Number(e.target.value))}
+                  value={selectedCameraId}
+                  onChange={(e) => setSelectedCameraId(e.target.value)}
                   disabled={isActive}
                   aria-label="使用するカメラを選択"
                 >
                   {cameras.length === 0 ? (
-                    <option value={0}>読み込み中…</option>
+                    <option value="">読み込み中…</option>
                   ) : (
                     cameras.map((cam) => (
-                      <option key={cam.Index} value={cam.Index}>
+                      <option key={cam.ID} value={cam.ID}>
                         {cam.Name}
                       </option>
                     ))
                   )}
                 </select>
               </div>
+              <div className="camera-capture-row" role="group" aria-label="取り込みの設定">
+                <select
+                  className="camera-select"
+                  value={`${captureSetting.Width}x${captureSetting.Height}`}
+                  onChange={(e) => {
+                    const [width, height] = e.target.value.split('x').map(Number);
+                    updateCaptureSetting({ Width: width, Height: height });
+                  }}
+                  aria-label="解像度"
+                >
+                  {RESOLUTION_OPTIONS.map((opt) => (
+                    <option key={opt.label} value={`${opt.width}x${opt.height}`}>
+                      {opt.label}
+                    </option>
+                  ))}
+                </select>
+                <select
+                  className="camera-select"
+                  value={captureSetting.MaxFPS}
+                  onChange={(e) => updateCaptureSetting({ MaxFPS: Number(e.target.value) })}
+                  aria-label="最大フレームレート"
+                >
+                  {FPS_OPTIONS.map((fps) => (
+                    <option key={fps} value={fps}>
+                      {fps === 0 ? 'FPS 制限なし' : `${fps} fps`}
+                    </option>
+                  ))}
+                </select>
+                <select
+                  className="camera-select"
+                  value={captureSetting.Rotation}
+                  onChange={(e) => updateCaptureSetting({ Rotation: Number(e.target.value) })}
+                  aria-label="回転"
+                >
+                  {ROTATION_OPTIONS.map((deg) => (
+                    <option key={deg} value={deg}>
+                      回転 {deg}°
+                    </option>
+                  ))}
+                </select>
+                <label className="camera-capture-mirror">
+                  <input
+                    type="checkbox"
+                    checked={captureSetting.Mirror}
+                    onChange={(e) => updateCaptureSetting({ Mirror: e.target.checked })}
+                  />
+                  左右反転
+                </label>
+              </div>
+              <div className="camera-roi-row">
+                <button
+                  type="button"
+                  className="camera-roi-btn"
+                  aria-pressed={roiEditing}
+                  onClick={() => setRoiEditing((prev) => !prev)}
+                >
+                  {roiEditing ? 'プレビュー上をドラッグして範囲を指定…' : '検出範囲を指定'}
+                </button>
+                {roi.Width > 0 && roi.Height > 0 && (
+                  <button type="button" className="camera-roi-btn" onClick={() => saveRoi(FULL_ROI)}>
+                    検出範囲を解除
+                  </button>
+                )}
+              </div>
               <div className="camera-wrap">
                 <div className="camera-viewport">
                   {!isActive && (
@@ -326,14 +795,26 @@ function App() {
                       カメラ未開始
                     </div>
                   )}
-                  {isActive && !previewDataUrl && (
+                  {isActive && browserStream && (
+                    <video
+                      ref={browserVideoRef}
+                      className="camera-preview-img"
+                      width={320}
+                      height={240}
+                      autoPlay
+                      muted
+                      playsInline
+                      aria-label="カメラプレビュー"
+                    />
+                  )}
+                  {isActive && !browserStream && !previewUrl && (
                     <div className="camera-placeholder" aria-live="polite" aria-busy="true">
                       カメラ稼働中…
                     </div>
                   )}
-                  {isActive && previewDataUrl && (
+                  {isActive && !browserStream && previewUrl && (
                     <img
-                      src={previewDataUrl}
+                      src={previewUrl}
                       alt="カメラプレビュー"
                       className="camera-preview-img"
                       width={320}
@@ -389,6 +870,30 @@ function App() {
                     />
                   </div>
                 )}
+                {(roiDraft ?? roi).Width > 0 && (roiDraft ?? roi).Height > 0 && (
+                  <div className="face-overlay roi-overlay" aria-hidden="true">
+                    <div
+                      className="roi-box"
+                      style={{
+                        left: `${(roiDraft ?? roi).X * 100}%`,
+                        top: `${(roiDraft ?? roi).Y * 100}%`,
+                        width: `${(roiDraft ?? roi).Width * 100}%`,
+                        height: `${(roiDraft ?? roi).Height * 100}%`,
+                      }}
+                    />
+                  </div>
+                )}
+                {roiEditing && (
+                  <div
+                    ref={roiOverlayRef}
+                    className="roi-draw-overlay"
+                    aria-label="検出範囲をドラッグで指定"
+                    onPointerDown={handleRoiPointerDown}
+                    onPointerMove={handleRoiPointerMove}
+                    onPointerUp={handleRoiPointerUp}
+                    onPointerCancel={handleRoiPointerUp}
+                  />
+                )}
                 {faceData && (
                   <div className="face-status-inline" aria-live="polite">
                     <span className="face-status-inline__state">状態: {faceData.state}</span>
@@ -398,6 +903,27 @@ function App() {
                   </div>
                 )}
               </div>
+              {isActive && cameraStatus && cameraStatus.state !== 'active' && (
+                <p className="error-msg" role="status" aria-live="polite">
+                  {cameraStatus.state === 'reconnecting'
+                    ? `カメラに再接続しています…（${cameraStatus.attempt ?? 1} 回目）`
+                    : 'カメラからの映像が途絶えました'}
+                </p>
+              )}
+              {diagnostics && (
+                <p className="camera-diagnostics" aria-live="off">
+                  検出 {diagnostics.detectFps.toFixed(1)} fps（{DETECT_MODE_LABELS[diagnostics.mode] ?? diagnostics.mode}
+                  {diagnostics.mode !== 'full' &&
+                    `・上限 ${diagnostics.targetFps > 0 ? `${diagnostics.targetFps} fps` : 'なし'}`}
+                  ）・上下の動き {diagnostics.verticalSpeed.toFixed(2)}/秒・変化 {(diagnostics.changed * 100).toFixed(0)}%
+                </p>
+              )}
+              {isActive && lighting && (
+                <p className="error-msg" role="status" aria-live="polite">
+                  {LIGHTING_MESSAGES[lighting.condition] ?? '撮影条件がよくありません'}
+                  {lighting.corrected && '（明るさを補正して検出しています）'}
+                </p>
+              )}
               {error && (
                 <p id="camera-error" className="error-msg" role="alert">
                   {error}
@@ -407,6 +933,7 @@ function App() {
           )}
         </main>
 
+        {!headless && (
         <footer className="app-footer">
           {!quitConfirmOpen ? (
             <button
@@ -459,6 +986,7 @@ function App() {
             </div>
           )}
         </footer>
+        )}
       </div>
     </>
   );
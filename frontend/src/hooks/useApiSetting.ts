@@ -0,0 +1,36 @@
+import { useCallback, useEffect, useState } from "react";
+import { SettingsService } from "../../bindings/github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service";
+import type { APISetting } from "../../bindings/github.com/kikils/desk-squat-tracker/internal/domain/entity";
+
+// ローカル HTTP API（ダッシュボードやボット向け）の設定を読み込み、変更を保存する。
+export function useApiSetting(enabled: boolean) {
+  const [setting, setSetting] = useState<APISetting | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [saving, setSaving] = useState(false);
+
+  useEffect(() => {
+    if (!enabled) return;
+    SettingsService.GetSetting()
+      .then((out) => setSetting(out?.API ?? null))
+      .catch((err) => console.warn("GetSetting error:", err));
+  }, [enabled]);
+
+  const update = useCallback((next: { enabled: boolean; port: number; regenerateToken?: boolean }) => {
+    setSaving(true);
+    setError(null);
+    SettingsService.UpdateAPISetting(next.enabled, next.port, next.regenerateToken ?? false)
+      .then((saved) => {
+        if (saved) setSetting(saved);
+      })
+      .catch((err) => {
+        setError(err instanceof Error ? err.message : "API の設定を保存できませんでした");
+        // 保存はできていて待ち受けだけ失敗した場合もあるので読み直す
+        SettingsService.GetSetting()
+          .then((out) => setSetting(out?.API ?? null))
+          .catch(() => {});
+      })
+      .finally(() => setSaving(false));
+  }, []);
+
+  return { setting, error, saving, update };
+}
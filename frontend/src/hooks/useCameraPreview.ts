@@ -0,0 +1,60 @@
+import { useEffect, useState } from "react";
+import { CameraService } from "../../bindings/github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service";
+
+// 取得に失敗したときに次を試すまでの間隔
+const RETRY_INTERVAL_MS = 1000;
+
+// Go 側のプレビュー用エンドポイント（CameraService.PreviewURL）を、enabled かつ画面が見えている間だけ
+// ロングポーリングし、最新の JPEG を object URL で返す。取得をやめると Go 側はプレビューのエンコードを止める。
+export function useCameraPreview(enabled: boolean): string | null {
+  const [visible, setVisible] = useState(document.visibilityState === "visible");
+  const [previewUrl, setPreviewUrl] = useState<string | null>(null);
+
+  useEffect(() => {
+    const onChange = () => setVisible(document.visibilityState === "visible");
+    document.addEventListener("visibilitychange", onChange);
+    return () => document.removeEventListener("visibilitychange", onChange);
+  }, []);
+
+  useEffect(() => {
+    if (!enabled) {
+      setPreviewUrl(null);
+      return;
+    }
+    if (!visible) return;
+    const controller = new AbortController();
+    let objectUrl: string | null = null;
+    const loop = async () => {
+      const base = await CameraService.PreviewURL();
+      let seq = "0";
+      while (!controller.signal.aborted) {
+        try {
+          const res = await fetch(`${base}&after=${seq}`, { signal: controller.signal, cache: "no-store" });
+          if (res.status === 204) continue;
+          if (!res.ok) throw new Error(`preview: ${res.status}`);
+          seq = res.headers.get("X-Preview-Seq") ?? seq;
+          const next = URL.createObjectURL(await res.blob());
+          if (controller.signal.aborted) {
+            URL.revokeObjectURL(next);
+            return;
+          }
+          if (objectUrl) URL.revokeObjectURL(objectUrl);
+          objectUrl = next;
+          setPreviewUrl(next);
+        } catch (err) {
+          if (controller.signal.aborted) return;
+          console.warn("Preview error:", err);
+          await new Promise((resolve) => setTimeout(resolve, RETRY_INTERVAL_MS));
+        }
+      }
+    };
+    loop().catch((err) => console.warn("PreviewURL error:", err));
+    return () => {
+      controller.abort();
+      if (objectUrl) URL.revokeObjectURL(objectUrl);
+      setPreviewUrl(null);
+    };
+  }, [enabled, visible]);
+
+  return previewUrl;
+}
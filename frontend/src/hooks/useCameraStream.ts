@@ -1,30 +1,147 @@
-import { useCallback, useState } from "react";
+import { useCallback, useEffect, useRef, useState } from "react";
+import { Events } from "@wailsio/runtime";
 import { CameraService } from "../../bindings/github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service";
 
+// ブラウザのカメラから Go に送るフレームの間隔と JPEG 品質
+const PUSH_INTERVAL_MS = 100;
+const PUSH_JPEG_QUALITY = 0.75;
+const PUSH_MAX_WIDTH = 640;
+
+// CameraService.CameraStatus（cameraStatus イベント）
+export interface CameraStatusPayload {
+  state: "active" | "stalled" | "disconnected" | "reconnecting" | "ended" | "stopped";
+  deviceId: string;
+  deviceName: string;
+  attempt?: number;
+  error?: string;
+}
+
+// CameraService.LightingWarning（lightingWarning イベント）
+export interface LightingWarningPayload {
+  condition: "ok" | "too_dark" | "backlit" | "overexposed";
+  mean: number;
+  centerMean: number;
+  surroundMean: number;
+  corrected: boolean;
+}
+
 export function useCameraStream() {
   const [isActive, setIsActive] = useState(false);
   const [error, setError] = useState<string | null>(null);
+  const [browserStream, setBrowserStream] = useState<MediaStream | null>(null);
+  const [status, setStatus] = useState<CameraStatusPayload | null>(null);
+  const [lighting, setLighting] = useState<LightingWarningPayload | null>(null);
+  const browserStopRef = useRef<(() => void) | null>(null);
+
+  const stopBrowser = useCallback(() => {
+    browserStopRef.current?.();
+    browserStopRef.current = null;
+    setBrowserStream(null);
+  }, []);
+
+  // getUserMedia で取得した映像を canvas で JPEG にし、前の PushFrame が終わってから次を送る
+  const startBrowser = useCallback(async () => {
+    const media = await navigator.mediaDevices.getUserMedia({ video: true, audio: false });
+    const video = document.createElement("video");
+    video.muted = true;
+    video.playsInline = true;
+    video.srcObject = media;
+    await video.play();
+
+    const canvas = document.createElement("canvas");
+    const ctx = canvas.getContext("2d");
+    let stopped = false;
+    let timer: ReturnType<typeof setTimeout> | undefined;
+
+    const pushNext = async () => {
+      if (stopped) return;
+      const started = performance.now();
+      if (ctx && video.videoWidth > 0) {
+        const scale = Math.min(1, PUSH_MAX_WIDTH / video.videoWidth);
+        canvas.width = Math.round(video.videoWidth * scale);
+        canvas.height = Math.round(video.videoHeight * scale);
+        ctx.drawImage(video, 0, 0, canvas.width, canvas.height);
+        const dataURL = canvas.toDataURL("image/jpeg", PUSH_JPEG_QUALITY);
+        try {
+          await CameraService.PushFrame(dataURL.slice(dataURL.indexOf(",") + 1));
+        } catch (e) {
+          const msg = e instanceof Error ? e.message : "フレームを送信できませんでした";
+          setError(msg);
+        }
+      }
+      if (stopped) return;
+      const elapsed = performance.now() - started;
+      timer = setTimeout(pushNext, Math.max(0, PUSH_INTERVAL_MS - elapsed));
+    };
 
-  const start = useCallback(async (deviceIndex: number = 0) => {
+    browserStopRef.current = () => {
+      stopped = true;
+      if (timer !== undefined) clearTimeout(timer);
+      media.getTracks().forEach((track) => track.stop());
+      video.srcObject = null;
+    };
+    setBrowserStream(media);
+    pushNext();
+  }, []);
+
+  const start = useCallback(async (deviceId: string, browser: boolean = false) => {
     setError(null);
     try {
-      await CameraService.StartCapture(deviceIndex);
+      // ブラウザのカメラでも StartCapture を呼び、選んだカメラとして保存させる
+      await CameraService.StartCapture(deviceId);
+      if (browser) await startBrowser();
       setIsActive(true);
     } catch (e) {
+      stopBrowser();
       const msg = e instanceof Error ? e.message : "カメラを起動できませんでした";
       setError(msg);
       setIsActive(false);
     }
-  }, []);
+  }, [startBrowser, stopBrowser]);
 
   const stop = useCallback(() => {
+    stopBrowser();
     CameraService.StopCapture();
     setIsActive(false);
+  }, [stopBrowser]);
+
+  useEffect(() => stopBrowser, [stopBrowser]);
+
+  // 起動時に前回のカメラで自動的にキャプチャが始まっていれば、スイッチをオンにする
+  useEffect(() => {
+    CameraService.CaptureDeviceID()
+      .then((id) => {
+        if (id) setIsActive(true);
+      })
+      .catch((err) => console.warn("CaptureDeviceID error:", err));
+  }, []);
+
+  // 切断・再接続は Go 側が自動で行う。録画ファイルが終端に達したときだけスイッチをオフに戻す
+  useEffect(() => {
+    return Events.On("cameraStatus", (ev: { data?: CameraStatusPayload | null }) => {
+      const payload = ev.data;
+      if (!payload) return;
+      setStatus(payload);
+      if (payload.state === "active") setIsActive(true);
+      if (payload.state === "ended") setIsActive(false);
+    });
+  }, []);
+
+  // 撮影条件は Go 側で間引かれて届く。"ok" で警告を消す
+  useEffect(() => {
+    return Events.On("lightingWarning", (ev: { data?: LightingWarningPayload | null }) => {
+      const payload = ev.data;
+      if (!payload) return;
+      setLighting(payload.condition === "ok" ? null : payload);
+    });
   }, []);
 
   return {
     isActive,
+    lighting,
     error,
+    status,
+    browserStream,
     start,
     stop,
   };
@@ -0,0 +1,31 @@
+import { useCallback, useEffect, useState } from "react";
+import { SettingsService } from "../../bindings/github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service";
+import type { MQTTSettingOutput, UpdateMQTTSettingInput } from "../../bindings/github.com/kikils/desk-squat-tracker/internal/usecase";
+
+// MQTT（Home Assistant などのダッシュボード向け）の設定を読み込み、変更を保存する。
+// パスワードは読み出せないので、Password が null なら保存済みのパスワードのまま保存する。
+export function useMqttSetting(enabled: boolean) {
+  const [setting, setSetting] = useState<MQTTSettingOutput | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [saving, setSaving] = useState(false);
+
+  useEffect(() => {
+    if (!enabled) return;
+    SettingsService.GetSetting()
+      .then((out) => setSetting(out?.MQTT ?? null))
+      .catch((err) => console.warn("GetSetting error:", err));
+  }, [enabled]);
+
+  const update = useCallback((next: UpdateMQTTSettingInput) => {
+    setSaving(true);
+    setError(null);
+    SettingsService.UpdateMQTTSetting(next)
+      .then((saved) => {
+        if (saved) setSetting(saved);
+      })
+      .catch((err) => setError(err instanceof Error ? err.message : "MQTT の設定を保存できませんでした"))
+      .finally(() => setSaving(false));
+  }, []);
+
+  return { setting, error, saving, update };
+}
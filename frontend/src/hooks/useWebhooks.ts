@@ -0,0 +1,71 @@
+import { useCallback, useEffect, useState } from "react";
+import { SettingsService, WebhookService } from "../../bindings/github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service";
+import { Webhook } from "../../bindings/github.com/kikils/desk-squat-tracker/internal/domain/entity";
+
+function errorMessage(err: unknown, fallback: string) {
+  return err instanceof Error ? err.message : fallback;
+}
+
+// rep・セット・目標の Webhook と 1 日の目標回数を読み込み、変更を保存する。
+export function useWebhooks(enabled: boolean) {
+  const [webhooks, setWebhooks] = useState<Webhook[]>([]);
+  const [dailyGoal, setDailyGoal] = useState<number | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [notice, setNotice] = useState<string | null>(null);
+  const [saving, setSaving] = useState(false);
+
+  const reload = useCallback(() => {
+    WebhookService.ListWebhooks()
+      .then((list) => setWebhooks(list ?? []))
+      .catch((err) => console.warn("ListWebhooks error:", err));
+  }, []);
+
+  useEffect(() => {
+    if (!enabled) return;
+    reload();
+    SettingsService.GetSetting()
+      .then((out) => setDailyGoal(out?.DailyGoal ?? 0))
+      .catch((err) => console.warn("GetSetting error:", err));
+  }, [enabled, reload]);
+
+  const run = useCallback(
+    (action: () => Promise<unknown>, fallback: string, done?: string) => {
+      setSaving(true);
+      setError(null);
+      setNotice(null);
+      action()
+        .then(() => {
+          if (done) setNotice(done);
+        })
+        .catch((err) => setError(errorMessage(err, fallback)))
+        .finally(() => {
+          setSaving(false);
+          reload();
+        });
+    },
+    [reload]
+  );
+
+  const save = useCallback(
+    (webhook: Partial<Webhook>) => run(() => WebhookService.SaveWebhook(new Webhook(webhook)), "Webhook を保存できませんでした"),
+    [run]
+  );
+  const remove = useCallback(
+    (id: string) => run(() => WebhookService.DeleteWebhook(id), "Webhook を削除できませんでした"),
+    [run]
+  );
+  const test = useCallback(
+    (id: string) => run(() => WebhookService.SendTestWebhook(id), "テストを送れませんでした", "テストを送りました"),
+    [run]
+  );
+  const updateGoal = useCallback(
+    (goal: number) =>
+      run(
+        () => SettingsService.UpdateDailyGoal(goal).then(() => setDailyGoal(goal)),
+        "目標回数を保存できませんでした"
+      ),
+    [run]
+  );
+
+  return { webhooks, dailyGoal, error, notice, saving, save, remove, test, updateGoal };
+}
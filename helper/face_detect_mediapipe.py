@@ -3,9 +3,12 @@
 Face detection using MediaPipe.
 - One-shot: python face_detect_mediapipe.py [image_path]  # or stdin
   Outputs one JSON line to stdout.
-- Server (RPC): python face_detect_mediapipe.py --serve [--port PORT]
+- Server (RPC): python face_detect_mediapipe.py --serve [--host HOST] [--port PORT]
   HTTP server: POST /detect with body=image bytes, response=JSON.
-  GET /health for readiness. Model loaded once at startup.
+  GET /health for readiness and handshake (protocol version, model, endpoints, options).
+  Model loaded once at startup.
+  --port 0 binds a free ephemeral port. Once listening, one line
+  "LISTENING HOST:PORT" is written to stdout so the parent can find the server.
 """
 import argparse
 import json
@@ -13,6 +16,7 @@ import sys
 from http.server import BaseHTTPRequestHandler, HTTPServer
 from pathlib import Path
 from threading import Lock
+from urllib.parse import parse_qs, urlsplit
 
 import cv2
 import numpy as np
@@ -24,10 +28,64 @@ try:
 except Exception:
     mp_face_detection = None
 
+# Handshake returned by GET /health. Bump PROTOCOL_VERSION on any incompatible change
+# to the /detect request or response; the Go app refuses versions it does not know.
+PROTOCOL_VERSION = 1
+MODEL_NAME = "mediapipe/face_detection/short_range"
+ENDPOINTS = ["/health", "/detect"]
+# Optional /detect features the Go side may enable:
+#   multi_face: ?faces=all adds a "faces" list with every detection
+#   landmarks:  ?landmarks=1 adds "landmarks" (pixel keypoints) to each face
+OPTIONS = ["multi_face", "landmarks"]
 
-def detect_one(image: "np.ndarray", face_detection: "mp.solutions.face_detection.FaceDetection | None" = None) -> dict | None:
+
+def handshake() -> dict:
+    return {
+        "ok": True,
+        "protocol_version": PROTOCOL_VERSION,
+        "model": MODEL_NAME,
+        "endpoints": ENDPOINTS,
+        "options": OPTIONS,
+    }
+
+
+def _face_dict(detection, w: int, h: int, with_landmarks: bool) -> dict:
+    bbox = detection.location_data.relative_bounding_box
+    x = int(bbox.xmin * w)
+    y = int(bbox.ymin * h)
+    width = int(bbox.width * w)
+    height = int(bbox.height * h)
+    x = max(0, min(x, w - 1))
+    y = max(0, min(y, h - 1))
+    width = max(1, min(width, w - x))
+    height = max(1, min(height, h - y))
+    face = {
+        "x": x,
+        "y": y,
+        "width": width,
+        "height": height,
+        "score": float(detection.score[0]),
+    }
+    if with_landmarks:
+        face["landmarks"] = [
+            {
+                "x": max(0, min(int(kp.x * w), w - 1)),
+                "y": max(0, min(int(kp.y * h), h - 1)),
+            }
+            for kp in detection.location_data.relative_keypoints
+        ]
+    return face
+
+
+def detect_one(
+    image: "np.ndarray",
+    face_detection: "mp.solutions.face_detection.FaceDetection | None" = None,
+    all_faces: bool = False,
+    with_landmarks: bool = False,
+) -> dict | None:
     """Run face detection on one image. Returns dict or None if no face.
-    If face_detection is provided (e.g. from server), it is reused; else a new one is created (one-shot)."""
+    If face_detection is provided (e.g. from server), it is reused; else a new one is created (one-shot).
+    The best face is always returned at the top level; all_faces adds every detection under "faces"."""
     if image is None or image.size == 0:
         return None
     h, w = image.shape[:2]
@@ -42,23 +100,13 @@ def detect_one(image: "np.ndarray", face_detection: "mp.solutions.face_detection
     if not results.detections:
         return None
     best = max(results.detections, key=lambda d: d.score[0])
-    bbox = best.location_data.relative_bounding_box
-    x = int(bbox.xmin * w)
-    y = int(bbox.ymin * h)
-    width = int(bbox.width * w)
-    height = int(bbox.height * h)
-    x = max(0, min(x, w - 1))
-    y = max(0, min(y, h - 1))
-    width = max(1, min(width, w - x))
-    height = max(1, min(height, h - y))
-    return {
-        "x": x,
-        "y": y,
-        "width": width,
-        "height": height,
-        "frame_width": w,
-        "frame_height": h,
-    }
+    out = _face_dict(best, w, h, with_landmarks)
+    del out["score"]
+    out["frame_width"] = w
+    out["frame_height"] = h
+    if all_faces:
+        out["faces"] = [_face_dict(d, w, h, with_landmarks) for d in results.detections]
+    return out
 
 
 def run_one_shot(path: Path | None, data: bytes | None) -> None:
@@ -91,20 +139,24 @@ def make_detect_handler(face_detection: "mp.solutions.face_detection.FaceDetecti
 
     class DetectHandler(BaseHTTPRequestHandler):
         def do_GET(self):
-            if self.path == "/health":
+            if urlsplit(self.path).path == "/health":
                 self.send_response(200)
                 self.send_header("Content-Type", "application/json")
                 self.end_headers()
-                self.wfile.write(b'{"ok":true}\n')
+                self.wfile.write((json.dumps(handshake()) + "\n").encode("utf-8"))
             else:
                 self.send_response(404)
                 self.end_headers()
 
         def do_POST(self):
-            if self.path != "/detect":
+            url = urlsplit(self.path)
+            if url.path != "/detect":
                 self.send_response(404)
                 self.end_headers()
                 return
+            query = parse_qs(url.query)
+            all_faces = query.get("faces", [""])[0] == "all"
+            with_landmarks = query.get("landmarks", [""])[0] == "1"
             content_length = int(self.headers.get("Content-Length", 0))
             if content_length <= 0 or content_length > 10 * 1024 * 1024:  # 10MB
                 self.send_response(400)
@@ -114,7 +166,7 @@ def make_detect_handler(face_detection: "mp.solutions.face_detection.FaceDetecti
             nparr = np.frombuffer(body, np.uint8)
             image = cv2.imdecode(nparr, cv2.IMREAD_COLOR)
             with _detector_lock:
-                out = detect_one(image, face_detection)
+                out = detect_one(image, face_detection, all_faces=all_faces, with_landmarks=with_landmarks)
             self.send_response(200)
             self.send_header("Content-Type", "application/json")
             self.end_headers()
@@ -129,27 +181,34 @@ def make_detect_handler(face_detection: "mp.solutions.face_detection.FaceDetecti
     return DetectHandler
 
 
-def run_server(port: int) -> None:
+LISTENING_PREFIX = "LISTENING "
+
+
+def run_server(host: str, port: int) -> None:
     with mp_face_detection.FaceDetection(
         model_selection=0, min_detection_confidence=0.5
     ) as face_detection:
         handler = make_detect_handler(face_detection)
-        with HTTPServer(("127.0.0.1", port), handler) as httpd:
-            print(f"face_detect_mediapipe: listening on 127.0.0.1:{port}", file=sys.stderr, flush=True)
+        with HTTPServer((host, port), handler) as httpd:
+            bound_host, bound_port = httpd.server_address[:2]
+            # Port handoff: the parent process reads this line to learn the actual port (--port 0).
+            print(f"{LISTENING_PREFIX}{bound_host}:{bound_port}", flush=True)
+            print(f"face_detect_mediapipe: listening on {bound_host}:{bound_port}", file=sys.stderr, flush=True)
             httpd.serve_forever()
 
 
 def main() -> None:
     parser = argparse.ArgumentParser(description="MediaPipe face detection (one-shot or RPC server)")
     parser.add_argument("--serve", action="store_true", help="Run HTTP server for RPC")
-    parser.add_argument("--port", type=int, default=8765, help="Server port (default 8765)")
+    parser.add_argument("--host", default="127.0.0.1", help="Server host (default 127.0.0.1)")
+    parser.add_argument("--port", type=int, default=8765, help="Server port (default 8765, 0 = pick a free port)")
     parser.add_argument("image_path", nargs="?", type=Path, help="Image file (one-shot mode)")
     args = parser.parse_args()
 
     if args.serve:
         if mp_face_detection is None:
             sys.exit(2)
-        run_server(args.port)
+        run_server(args.host, args.port)
         return
 
     # One-shot: image_path or stdin
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
@@ -14,30 +15,158 @@ const AppName = "desk-squat-tracker"
 var (
 	conf Config
 	once sync.Once
+
+	// faceDetectServerAddr は子プロセスから通知された実際の listen アドレス（host:port）。
+	faceDetectServerAddr atomic.Value
 )
 
 type Config struct {
 	FaceDetectServer FaceDetectServer
+	Camera           Camera
+	Recorder         Recorder
+	Demo             Demo
+	Lighting         Lighting
+	Detection        Detection
+	Preview          Preview
+	Server           Server
+	Workout          Workout
+	MQTT             MQTT
 }
 
 type FaceDetectServer struct {
 	ServerHost     string        `default:"127.0.0.1"`
-	ServerPort     int           `default:"8765"`
+	ServerPort     int           `default:"0"` // 0 のときはヘルパーが空きポートを選び、stdout で通知する
 	ScriptBasePath string        `default:"helper"`
 	ScriptName     string        `default:"face_detect_mediapipe.py"`
 	Timeout        time.Duration `default:"10s"`
 	Debug          bool          `default:"true"`
+	// HelperPath は起動する face_detect 実行ファイルを明示する（未指定なら実行ファイル横の face_detect）。
+	HelperPath string
+	// AllowUnverifiedHelper は埋め込みマニフェストによる SHA-256 検証を省略する（開発者向けの明示的なオプトイン）。
+	AllowUnverifiedHelper bool `default:"false"`
 }
 
+// ServerURL は顔検出サーバーのベース URL を返す。
+// 子プロセスからポートが通知済みであれば、設定値よりもそのアドレスを優先する。
 func (f FaceDetectServer) ServerURL() string {
+	if addr, _ := faceDetectServerAddr.Load().(string); addr != "" {
+		return "http://" + addr
+	}
 	return fmt.Sprintf("http://%s:%d", f.ServerHost, f.ServerPort)
 }
 
+// SetFaceDetectServerAddr はヘルパーが実際に listen しているアドレス（host:port）を記録する。
+// 空文字を渡すと設定値（ServerHost / ServerPort）に戻る。
+func SetFaceDetectServerAddr(addr string) {
+	faceDetectServerAddr.Store(addr)
+}
+
+// Camera はカメラ以外も含めたフレームソースの設定。
+type Camera struct {
+	NetworkURLs []string // MJPEG（multipart/x-mixed-replace）ストリームの URL。カンマ区切り
+	FilePaths   []string // 再生する連番画像ディレクトリ / MJPEG / Y4M ファイル。カンマ区切り
+	FileFPS     float64  `default:"30"` // フレームレート情報を持たないファイルのフレームレート
+	FileSpeed   float64  `default:"1"`  // ファイル再生の速度倍率（0 なら最速）
+}
+
+// Recorder はキャプチャセッションの記録設定（オフラインでの再現・しきい値調整用）。
+type Recorder struct {
+	Enabled bool   `default:"false"`
+	Dir     string // 空なら UserConfigDir/desk-squat-tracker/sessions
+	Privacy bool   `default:"false"` // true なら JPEG を保存せず顔の検出結果と判定のみ記録する
+}
+
+// Demo はカメラと顔検出ヘルパーの代わりにシミュレーターを使うデモモードの設定（DEMO_ENABLED=true で有効）。
+type Demo struct {
+	Enabled          bool          `default:"false"`
+	FPS              float64       `default:"15"`
+	FrameWidth       int           `default:"352"`
+	FrameHeight      int           `default:"288"`
+	Tempo            time.Duration `default:"3s"`   // 1 rep にかかる時間
+	Rest             time.Duration `default:"5s"`   // rep の間に立っている時間
+	Depth            float64       `default:"0.45"` // しゃがんだときに顔が下がる量（フレームの高さに対する比率）
+	Jitter           float64       `default:"3"`    // 顔の位置のノイズ（ピクセル）
+	DropoutRate      float64       `default:"0.05"` // 顔を見失う割合
+	PasserbyInterval time.Duration `default:"40s"`  // 背後を別の人が通る間隔（0 なら通らない）
+	PasserbyDuration time.Duration `default:"4s"`
+	Seed             uint64        `default:"1"`
+}
+
+// Lighting は暗所・逆光などの撮影条件の検出設定。
+type Lighting struct {
+	Correction     bool          `default:"true"` // 条件が悪い間、検出前に Y チャネルへガンマ補正をかける
+	RepeatInterval time.Duration `default:"1m"`   // 悪い条件が続く間の lightingWarning の再通知間隔（0 なら再通知しない）
+}
+
+// Detection は顔検出の頻度の設定。立っていて動きがない間は頻度を落としてバッテリーを節約する。
+type Detection struct {
+	IdleFPS       float64       `default:"2"`    // 0 なら常に全フレームを検出する
+	ActiveFPS     float64       `default:"0"`    // 動き始めた・スクワット中の頻度（0 なら上限なし）
+	VerticalSpeed float64       `default:"0.1"`  // 上下の動き（フレームの高さ / 秒）がこれ以上なら ActiveFPS にする
+	ChangeRatio   float64       `default:"0.25"` // 変化した画素の割合がこれ以上なら ActiveFPS にする
+	Hold          time.Duration `default:"3s"`   // 動きが止まってから IdleFPS に戻すまでの時間
+	// 前回の顔の周りだけを検出に回すときの探索範囲（顔の幅・高さに対する倍率）。0 なら追跡しない
+	TrackingWidth  float64 `default:"3"`
+	TrackingHeight float64 `default:"4"`
+}
+
+// Preview はカメラページのプレビューの設定。見ている画面がある間だけこの頻度・品質でエンコードする。
+type Preview struct {
+	FPS     float64 `default:"5"`
+	Quality int     `default:"75"` // JPEG の品質（1〜100）。検出と同じ 75 なら切り抜かない場合に検出と共用する
+}
+
+// Server はヘッドレス起動（-tags server）の HTTP サーバーの設定。
+// Docker などで使う WAILS_SERVER_HOST / WAILS_SERVER_PORT が設定されていればそちらが優先される。
+type Server struct {
+	Host string `default:"localhost"`
+	Port int    `default:"8080"`
+	// Token は画面とバインディングを開くためのトークン。空なら設定ディレクトリの server_token を使う（なければ生成する）
+	Token string
+}
+
+// Workout は rep からセット・目標の達成を見つける設定。
+type Workout struct {
+	SetRest time.Duration `default:"30s"` // これだけ rep がなければセットが終わったとみなす
+}
+
+// MQTT は MQTT で送る値の設定（ブローカーは設定画面で指定する）。
+type MQTT struct {
+	PresenceTimeout time.Duration `default:"15s"` // これだけ顔が見えなければ不在とみなす
+}
+
 func Get() Config {
 	once.Do(func() {
 		if err := envconfig.Process("facedetectserver", &conf.FaceDetectServer); err != nil {
 			log.Fatal(err.Error())
 		}
+		if err := envconfig.Process("camera", &conf.Camera); err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := envconfig.Process("recorder", &conf.Recorder); err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := envconfig.Process("demo", &conf.Demo); err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := envconfig.Process("lighting", &conf.Lighting); err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := envconfig.Process("detection", &conf.Detection); err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := envconfig.Process("preview", &conf.Preview); err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := envconfig.Process("server", &conf.Server); err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := envconfig.Process("workout", &conf.Workout); err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := envconfig.Process("mqtt", &conf.MQTT); err != nil {
+			log.Fatal(err.Error())
+		}
 	})
 	return conf
 }
@@ -3,13 +3,13 @@ package entity
 import "time"
 
 type Face struct {
-	Timestamp   time.Time
-	X           int // 左上 X
-	Y           int // 左上 Y
-	Width       int // 幅
-	Height      int // 高さ
-	FrameHeight int // フレームの高さ
-	FrameWidth  int // フレームの幅
+	Timestamp   time.Time // フレームの撮影時刻（検出した時刻ではない）
+	X           int       // 左上 X
+	Y           int       // 左上 Y
+	Width       int       // 幅
+	Height      int       // 高さ
+	FrameHeight int       // フレームの高さ
+	FrameWidth  int       // フレームの幅
 }
 
 type Faces []*Face
@@ -22,3 +22,27 @@ func (m *Face) CenterY() int {
 func (m *Face) TopY() int {
 	return m.Y
 }
+
+// Rect は顔の矩形を返す。
+func (m *Face) Rect() Rect {
+	return Rect{X: m.X, Y: m.Y, Width: m.Width, Height: m.Height}
+}
+
+// FromCrop は crop で切り抜いた画像上の顔を、frameWidth×frameHeight の元フレーム上の座標に戻したコピーを返す。
+func (m *Face) FromCrop(crop Rect, frameWidth, frameHeight int) *Face {
+	return m.translate(crop.X, crop.Y, frameWidth, frameHeight)
+}
+
+// ToCrop は元フレーム上の顔を、crop で切り抜いた画像上の座標に移したコピーを返す。
+func (m *Face) ToCrop(crop Rect) *Face {
+	return m.translate(-crop.X, -crop.Y, crop.Width, crop.Height)
+}
+
+func (m *Face) translate(dx, dy, frameWidth, frameHeight int) *Face {
+	copied := *m
+	copied.X += dx
+	copied.Y += dy
+	copied.FrameWidth = frameWidth
+	copied.FrameHeight = frameHeight
+	return &copied
+}
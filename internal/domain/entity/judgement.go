@@ -16,6 +16,21 @@ const (
 	DetectStateGoingUp
 )
 
+var detectStateLabels = map[DetectState]string{
+	DetectStateUnknown:   "unknown",
+	DetectStateStanding:  "standing",
+	DetectStateGoingDown: "going_down",
+	DetectStateBottom:    "bottom",
+	DetectStateGoingUp:   "going_up",
+}
+
+func (s DetectState) String() string {
+	if label, ok := detectStateLabels[s]; ok {
+		return label
+	}
+	return "unknown"
+}
+
 const (
 	DefaultTopRatio    = 0.7 // jusge going down ratio
 	DefaultBottomRatio = 0.6 // judge going up ratio
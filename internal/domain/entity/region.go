@@ -0,0 +1,67 @@
+package entity
+
+// Region はフレームに対する正規化した矩形（各値 0〜1）。幅か高さが 0 ならフレーム全体を表す。
+type Region struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// Rect はフレーム上のピクセル単位の矩形。
+type Rect struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// IsFull はフレーム全体を表すかを返す。
+func (r Region) IsFull() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// Valid は各値が 0〜1 に収まり、フレームからはみ出さないかを返す。
+func (r Region) Valid() bool {
+	if r.X < 0 || r.Y < 0 || r.Width < 0 || r.Height < 0 {
+		return false
+	}
+	return r.X+r.Width <= 1 && r.Y+r.Height <= 1
+}
+
+// Rect は frameWidth×frameHeight のフレーム上のピクセル矩形に変換する。少なくとも 1 ピクセルはフレーム内に残す。
+func (r Region) Rect(frameWidth, frameHeight int) Rect {
+	if r.IsFull() {
+		return Rect{Width: frameWidth, Height: frameHeight}
+	}
+	x0 := clampInt(int(r.X*float64(frameWidth)), 0, frameWidth-1)
+	y0 := clampInt(int(r.Y*float64(frameHeight)), 0, frameHeight-1)
+	x1 := clampInt(int((r.X+r.Width)*float64(frameWidth)+0.5), x0+1, frameWidth)
+	y1 := clampInt(int((r.Y+r.Height)*float64(frameHeight)+0.5), y0+1, frameHeight)
+	return Rect{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+func clampInt(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+// Empty は面積が 0 かを返す。
+func (r Rect) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// Intersect は r と o の重なる矩形を返す。重ならなければ Empty な矩形を返す。
+func (r Rect) Intersect(o Rect) Rect {
+	x0, y0 := max(r.X, o.X), max(r.Y, o.Y)
+	x1, y1 := min(r.X+r.Width, o.X+o.Width), min(r.Y+r.Height, o.Y+o.Height)
+	if x1 <= x0 || y1 <= y0 {
+		return Rect{}
+	}
+	return Rect{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+// Expand は中心を保ったまま幅を scaleX 倍、高さを scaleY 倍にした矩形を返す。
+func (r Rect) Expand(scaleX, scaleY float64) Rect {
+	w, h := int(float64(r.Width)*scaleX+0.5), int(float64(r.Height)*scaleY+0.5)
+	return Rect{X: r.X + r.Width/2 - w/2, Y: r.Y + r.Height/2 - h/2, Width: w, Height: h}
+}
@@ -1,14 +1,115 @@
 package entity
 
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
 type Setting struct {
 	TopRatio    float64 // しゃがみ始め判定（顔がこの比率より下に来たら GoingDown/Bottom）
 	BottomRatio float64 // 立ち上がり判定（顔がこの比率より上に来たら GoingUp/Standing）
+	CameraID    string  // 最後に選んだカメラの ID（起動時にこのカメラがあれば自動でキャプチャを開始する）
+
+	CaptureWidth  int     // 取り込む解像度の上限（0 ならソースの解像度のまま）
+	CaptureHeight int     // 縦長のフレームでは CaptureWidth と入れ替えて使う
+	MaxFPS        float64 // 取り込むフレームレートの上限（0 なら制限しない）
+	Rotation      int     // フレームを時計回りに回転する角度（0 / 90 / 180 / 270）
+	Mirror        bool    // 回転後に左右反転する
+
+	ROI Region // 顔検出の対象にする範囲（背後の人を無視するため）。空ならフレーム全体
+
+	API APISetting // ダッシュボードやボットから使うローカル HTTP API
+
+	DailyGoal int       // 1 日の目標回数（0 なら目標なし）。達したら goal の Webhook を送る
+	Webhooks  []Webhook // rep・セット・目標の達成を知らせる送信先
+
+	MQTT MQTTSetting // Home Assistant などに在席・状態・回数を知らせる MQTT ブローカー
+}
+
+// APISetting はローカル HTTP API の設定。Enabled のときだけ 127.0.0.1 で待ち受ける。
+type APISetting struct {
+	Enabled bool
+	Port    int
+	Token   string // Authorization: Bearer で渡すトークン。有効にしたときに生成する
+}
+
+// MQTTSetting は MQTT で在席・状態・今日の回数・最後の rep を送る設定。Enabled のときだけブローカーにつなぐ。
+type MQTTSetting struct {
+	Enabled         bool
+	Broker          string // tcp://host:1883 や ssl://host:8883（mqtt:// / mqtts:// も可）
+	Username        string
+	Password        string
+	TopicPrefix     string // 状態を送るトピックの接頭辞（<TopicPrefix>/presence など）
+	DiscoveryPrefix string // Home Assistant の MQTT Discovery の接頭辞。空なら Discovery を送らない
 }
 
+// Validate はブローカーの URL とトピックの接頭辞を検証する。無効な設定は検証しない。
+func (s MQTTSetting) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	u, err := url.Parse(s.Broker)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("broker must be a URL like tcp://host:1883, got %q", s.Broker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+	default:
+		return fmt.Errorf("broker scheme must be tcp, mqtt, ssl, tls or mqtts, got %q", u.Scheme)
+	}
+	// MQTT 3.1.1 ではユーザー名なしでパスワードだけを送れない（黙って捨てると認証できない理由が分からなくなる）
+	if s.Password != "" && s.Username == "" {
+		return fmt.Errorf("password requires a username")
+	}
+	if !validTopicPrefix(s.TopicPrefix) {
+		return fmt.Errorf("topic prefix must be non-empty and contain no '+', '#' or leading/trailing '/', got %q", s.TopicPrefix)
+	}
+	if s.DiscoveryPrefix != "" && !validTopicPrefix(s.DiscoveryPrefix) {
+		return fmt.Errorf("discovery prefix must contain no '+', '#' or leading/trailing '/', got %q", s.DiscoveryPrefix)
+	}
+	return nil
+}
+
+func validTopicPrefix(prefix string) bool {
+	return prefix != "" && !strings.ContainsAny(prefix, "+#\x00") &&
+		!strings.HasPrefix(prefix, "/") && !strings.HasSuffix(prefix, "/")
+}
+
+const (
+	DefaultCaptureWidth  = 352
+	DefaultCaptureHeight = 288
+	DefaultAPIPort       = 8787
+
+	DefaultMQTTTopicPrefix     = "desk-squat-tracker"
+	DefaultMQTTDiscoveryPrefix = "homeassistant"
+)
+
 // DefaultSetting はデフォルトの設定を返す。
 func DefaultSetting() *Setting {
 	return &Setting{
-		TopRatio:    DefaultTopRatio,
-		BottomRatio: DefaultBottomRatio,
+		TopRatio:      DefaultTopRatio,
+		BottomRatio:   DefaultBottomRatio,
+		CaptureWidth:  DefaultCaptureWidth,
+		CaptureHeight: DefaultCaptureHeight,
+		API:           APISetting{Port: DefaultAPIPort},
+		MQTT: MQTTSetting{
+			TopicPrefix:     DefaultMQTTTopicPrefix,
+			DiscoveryPrefix: DefaultMQTTDiscoveryPrefix,
+		},
+	}
+}
+
+// ValidRotation は回転角として使える値かを返す。
+func ValidRotation(rotation int) bool {
+	switch rotation {
+	case 0, 90, 180, 270:
+		return true
 	}
+	return false
+}
+
+// ValidPort は API が待ち受けるポートとして使える値かを返す（特権ポートは使わない）。
+func ValidPort(port int) bool {
+	return port >= 1024 && port <= 65535
 }
@@ -0,0 +1,117 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"text/template"
+	"time"
+)
+
+const defaultWebhookContentType = "application/json"
+
+// Webhook は WorkoutEvent を POST する送信先。
+type Webhook struct {
+	ID      string
+	Name    string
+	URL     string
+	Enabled bool
+	// Events は送る出来事の種類。空ならすべて送る
+	Events []WorkoutEventType
+	// Template は本文の Go text/template（.Type / .Timestamp / .TodayCount / .SetReps / .Goal と json 関数が使える）。
+	// 空なら WorkoutEvent を JSON にしたものを送る
+	Template    string
+	ContentType string // 空なら application/json
+}
+
+// Subscribes は t の出来事を送るかを返す。送信テストは常に送る。
+func (w Webhook) Subscribes(t WorkoutEventType) bool {
+	return t == WorkoutEventTest || len(w.Events) == 0 || slices.Contains(w.Events, t)
+}
+
+// Validate は URL・出来事の種類・テンプレートを検証する。
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL, got %q", w.URL)
+	}
+	for _, t := range w.Events {
+		if !ValidWorkoutEventType(t) {
+			return fmt.Errorf("unknown event %q", t)
+		}
+	}
+	if _, err := w.template(); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	return nil
+}
+
+// Render は ev を送るリクエストを作る。
+func (w Webhook) Render(ev WorkoutEvent) (*WebhookDelivery, error) {
+	tmpl, err := w.template()
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: template: %w", w.ID, err)
+	}
+	var body bytes.Buffer
+	if tmpl == nil {
+		err = json.NewEncoder(&body).Encode(webhookPayload(ev))
+	} else {
+		err = tmpl.Execute(&body, ev)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: render: %w", w.ID, err)
+	}
+	contentType := w.ContentType
+	if contentType == "" {
+		contentType = defaultWebhookContentType
+	}
+	return &WebhookDelivery{
+		WebhookID:   w.ID,
+		URL:         w.URL,
+		ContentType: contentType,
+		Body:        body.String(),
+		Event:       ev.Type,
+		Source:      ev,
+		CreatedAt:   ev.Timestamp,
+	}, nil
+}
+
+func (w Webhook) template() (*template.Template, error) {
+	if w.Template == "" {
+		return nil, nil
+	}
+	return template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(w.Template)
+}
+
+// webhookPayload はテンプレートが空のときに送る JSON。
+func webhookPayload(ev WorkoutEvent) any {
+	return struct {
+		Event      WorkoutEventType `json:"event"`
+		Timestamp  time.Time        `json:"timestamp"`
+		TodayCount int              `json:"todayCount"`
+		SetReps    int              `json:"setReps,omitempty"`
+		Goal       int              `json:"goal,omitempty"`
+	}{ev.Type, ev.Timestamp, ev.TodayCount, ev.SetReps, ev.Goal}
+}
+
+// WebhookDelivery は送信待ちの 1 件。本文は生成した時点のもので、送れるまで再送する。
+type WebhookDelivery struct {
+	ID          string
+	WebhookID   string
+	URL         string
+	ContentType string
+	Body        string
+	Event       WorkoutEventType
+	Source      WorkoutEvent // 本文の元になった出来事。送る前に Webhook を編集したら、これから作り直す
+	CreatedAt   time.Time
+	Attempts    int       // 失敗した回数
+	NextAttempt time.Time // 次に送る時刻（ゼロ値ならすぐ）
+	LastError   string
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// WorkoutEventType は rep の判定から導く、通知の対象になる出来事の種類。
+type WorkoutEventType string
+
+const (
+	WorkoutEventRep  WorkoutEventType = "rep"  // rep を 1 回数えた
+	WorkoutEventSet  WorkoutEventType = "set"  // 休憩を挟んで 1 セットが終わった
+	WorkoutEventGoal WorkoutEventType = "goal" // 今日の回数が 1 日の目標に達した
+	WorkoutEventTest WorkoutEventType = "test" // 設定画面からの送信テスト
+)
+
+// ValidWorkoutEventType は通知の対象として選べる種類かを返す（test は選べない）。
+func ValidWorkoutEventType(t WorkoutEventType) bool {
+	switch t {
+	case WorkoutEventRep, WorkoutEventSet, WorkoutEventGoal:
+		return true
+	}
+	return false
+}
+
+// WorkoutEvent は通知する出来事。Webhook のテンプレートにはこの値を渡す。
+type WorkoutEvent struct {
+	Type       WorkoutEventType
+	Timestamp  time.Time
+	TodayCount int // 今日の rep 数（出来事の時点）
+	SetReps    int // set: そのセットの rep 数。rep: いまのセットで何回目か
+	Goal       int // 1 日の目標（0 なら未設定）
+}
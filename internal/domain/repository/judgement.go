@@ -9,4 +9,6 @@ type JudgementRepository interface {
 	Save(judgement *entity.Judgement) error
 	GetLast() (*entity.Judgement, error)
 	CountRepsByDate(date civil.Date) (int, error)
+	// CountRepsByDateRange は from〜to（両端を含む）の日ごとの rep 数を返す。rep のない日は含めない。
+	CountRepsByDateRange(from, to civil.Date) (map[civil.Date]int, error)
 }
@@ -10,9 +10,11 @@
 package repository
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
+	civil "cloud.google.com/go/civil"
 	entity "github.com/kikils/desk-squat-tracker/internal/domain/entity"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -42,18 +44,18 @@ func (m *MockFaceRepository) EXPECT() *MockFaceRepositoryMockRecorder {
 }
 
 // Detect mocks base method.
-func (m *MockFaceRepository) Detect(frame []byte, t time.Time) (*entity.Face, error) {
+func (m *MockFaceRepository) Detect(ctx context.Context, frame []byte, t time.Time) (*entity.Face, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Detect", frame, t)
+	ret := m.ctrl.Call(m, "Detect", ctx, frame, t)
 	ret0, _ := ret[0].(*entity.Face)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Detect indicates an expected call of Detect.
-func (mr *MockFaceRepositoryMockRecorder) Detect(frame, t any) *gomock.Call {
+func (mr *MockFaceRepositoryMockRecorder) Detect(ctx, frame, t any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Detect", reflect.TypeOf((*MockFaceRepository)(nil).Detect), frame, t)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Detect", reflect.TypeOf((*MockFaceRepository)(nil).Detect), ctx, frame, t)
 }
 
 // MockJudgementRepository is a mock of JudgementRepository interface.
@@ -80,6 +82,36 @@ func (m *MockJudgementRepository) EXPECT() *MockJudgementRepositoryMockRecorder
 	return m.recorder
 }
 
+// CountRepsByDate mocks base method.
+func (m *MockJudgementRepository) CountRepsByDate(date civil.Date) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRepsByDate", date)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRepsByDate indicates an expected call of CountRepsByDate.
+func (mr *MockJudgementRepositoryMockRecorder) CountRepsByDate(date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRepsByDate", reflect.TypeOf((*MockJudgementRepository)(nil).CountRepsByDate), date)
+}
+
+// CountRepsByDateRange mocks base method.
+func (m *MockJudgementRepository) CountRepsByDateRange(from, to civil.Date) (map[civil.Date]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRepsByDateRange", from, to)
+	ret0, _ := ret[0].(map[civil.Date]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRepsByDateRange indicates an expected call of CountRepsByDateRange.
+func (mr *MockJudgementRepositoryMockRecorder) CountRepsByDateRange(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRepsByDateRange", reflect.TypeOf((*MockJudgementRepository)(nil).CountRepsByDateRange), from, to)
+}
+
 // GetLast mocks base method.
 func (m *MockJudgementRepository) GetLast() (*entity.Judgement, error) {
 	m.ctrl.T.Helper()
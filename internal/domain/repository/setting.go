@@ -7,4 +7,6 @@ import (
 type SettingRepository interface {
 	Get() (*entity.Setting, error)
 	Save(setting *entity.Setting) error
+	// Update は読み込み→fn による変更→保存を 1 つのロック内で行う。fn がエラーを返した場合は保存しない。
+	Update(fn func(setting *entity.Setting) error) error
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+)
+
+// WebhookDeliveryRepository は Webhook の配送。Enqueue したものは再起動をまたいで、送れるまで間隔を空けて再送する。
+type WebhookDeliveryRepository interface {
+	Enqueue(delivery *entity.WebhookDelivery) error
+	// Send はキューを通さずにすぐ送り、失敗すればそのエラーを返す（送信テスト用）。
+	Send(ctx context.Context, delivery *entity.WebhookDelivery) error
+	// Retarget は編集した webhook をキューに残っている分に反映する。宛先・本文を作り直し、
+	// 無効にした Webhook や購読をやめた出来事の分は捨てる。
+	Retarget(webhook *entity.Webhook) error
+	// Discard は削除した Webhook 宛てでキューに残っている分を捨てる。
+	Discard(webhookID string) error
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+)
+
+// DefaultSetRest はこれだけ rep がなければセットが終わったとみなす間隔。
+const DefaultSetRest = 30 * time.Second
+
+// WorkoutTracker は判定の結果から rep・セットの終わり・1 日の目標の達成を見つける。
+type WorkoutTracker interface {
+	// Observe は判定のたびに呼び、新しく起きた出来事を返す。
+	Observe(judgement *entity.Judgement) ([]entity.WorkoutEvent, error)
+	// Flush はキャプチャを止めたときに呼び、続いているセットを終わらせる。
+	Flush() ([]entity.WorkoutEvent, error)
+	// Expire は休憩が SetRest 続いていれば、いまのセットを終わらせる。顔が映らず判定が来ない間も
+	// セットを終わらせられるよう、RestDeadline の時刻にタイマーから呼ぶ。
+	Expire(now time.Time) ([]entity.WorkoutEvent, error)
+	// RestDeadline は続いているセットが休憩で終わる時刻。セットが無ければ ok は false。
+	RestDeadline() (deadline time.Time, ok bool)
+}
+
+type workoutTrackerImpl struct {
+	JudgementRepository repository.JudgementRepository
+	SettingRepository   repository.SettingRepository
+	SetRest             time.Duration
+
+	mu      sync.Mutex
+	setReps int       // いまのセットの rep 数
+	lastRep time.Time // いまのセットの最後の rep
+}
+
+func NewWorkoutTracker(judgementRepository repository.JudgementRepository, settingRepository repository.SettingRepository, setRest time.Duration) WorkoutTracker {
+	if setRest <= 0 {
+		setRest = DefaultSetRest
+	}
+	return &workoutTrackerImpl{
+		JudgementRepository: judgementRepository,
+		SettingRepository:   settingRepository,
+		SetRest:             setRest,
+	}
+}
+
+func (t *workoutTrackerImpl) Observe(judgement *entity.Judgement) ([]entity.WorkoutEvent, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// タイマーより先に判定が来ても、休憩が続いていればここでセットを終わらせる
+	events, err := t.expireLocked(judgement.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if !judgement.IsRepCompleted {
+		return events, nil
+	}
+
+	// 判定は保存済みなので、今日の回数にはこの rep も含まれる
+	todayCount, err := t.JudgementRepository.CountRepsByDate(judgement.Date())
+	if err != nil {
+		return nil, err
+	}
+	setting, err := t.SettingRepository.Get()
+	if err != nil {
+		return nil, err
+	}
+	t.setReps++
+	t.lastRep = judgement.Timestamp
+	events = append(events, entity.WorkoutEvent{
+		Type:       entity.WorkoutEventRep,
+		Timestamp:  judgement.Timestamp,
+		TodayCount: todayCount,
+		SetReps:    t.setReps,
+		Goal:       setting.DailyGoal,
+	})
+	if setting.DailyGoal > 0 && todayCount == setting.DailyGoal {
+		events = append(events, entity.WorkoutEvent{
+			Type:       entity.WorkoutEventGoal,
+			Timestamp:  judgement.Timestamp,
+			TodayCount: todayCount,
+			Goal:       setting.DailyGoal,
+		})
+	}
+	return events, nil
+}
+
+func (t *workoutTrackerImpl) Expire(now time.Time) ([]entity.WorkoutEvent, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.expireLocked(now)
+}
+
+func (t *workoutTrackerImpl) expireLocked(now time.Time) ([]entity.WorkoutEvent, error) {
+	if t.setReps == 0 || now.Sub(t.lastRep) < t.SetRest {
+		return nil, nil
+	}
+	ev, err := t.endSet()
+	if err != nil {
+		return nil, err
+	}
+	return []entity.WorkoutEvent{ev}, nil
+}
+
+func (t *workoutTrackerImpl) RestDeadline() (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.setReps == 0 {
+		return time.Time{}, false
+	}
+	return t.lastRep.Add(t.SetRest), true
+}
+
+func (t *workoutTrackerImpl) Flush() ([]entity.WorkoutEvent, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.setReps == 0 {
+		return nil, nil
+	}
+	ev, err := t.endSet()
+	if err != nil {
+		return nil, err
+	}
+	return []entity.WorkoutEvent{ev}, nil
+}
+
+// endSet はいまのセットを終わらせ、その出来事を返す。時刻はセットの最後の rep。
+func (t *workoutTrackerImpl) endSet() (entity.WorkoutEvent, error) {
+	todayCount, err := t.JudgementRepository.CountRepsByDate(civil.DateOf(t.lastRep))
+	if err != nil {
+		return entity.WorkoutEvent{}, err
+	}
+	setting, err := t.SettingRepository.Get()
+	if err != nil {
+		return entity.WorkoutEvent{}, err
+	}
+	ev := entity.WorkoutEvent{
+		Type:       entity.WorkoutEventSet,
+		Timestamp:  t.lastRep,
+		TodayCount: todayCount,
+		SetReps:    t.setReps,
+		Goal:       setting.DailyGoal,
+	}
+	t.setReps, t.lastRep = 0, time.Time{}
+	return ev, nil
+}
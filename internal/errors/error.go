@@ -24,7 +24,10 @@ func (e *Error) Errorf(format string, args ...interface{}) error {
 }
 
 var (
-	ErrNotFound = &Error{err: xerrors.New("not found")}
+	ErrNotFound  = &Error{err: xerrors.New("not found")}
+	ErrIntegrity = &Error{err: xerrors.New("integrity check failed")}
+	// ErrInvalidArgument は入力値の検証に失敗したことを表す（HTTP API では 400 にする）。
+	ErrInvalidArgument = &Error{err: xerrors.New("invalid argument")}
 )
 
 func Is(err error, target error) bool {
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// subscriberBuffer は SSE の接続ごとに溜めるイベント数。読み出しが追いつかない接続ではそれ以上を捨てる。
+const subscriberBuffer = 32
+
+// event は SSE で送る 1 件のイベント。data は JSON にエンコード済み。
+type event struct {
+	name string
+	data []byte
+}
+
+// broker は Publish されたイベントを SSE の各接続に配る。
+type broker struct {
+	mu   sync.Mutex
+	subs map[chan event]struct{}
+}
+
+func (b *broker) subscribe() chan event {
+	ch := make(chan event, subscriberBuffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[chan event]struct{})
+	}
+	b.subs[ch] = struct{}{}
+	return ch
+}
+
+func (b *broker) unsubscribe(ch chan event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, ch)
+}
+
+// publish は接続しているクライアントがいればイベントを送る。遅いクライアントのために待つことはしない。
+func (b *broker) publish(name string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subs) == 0 {
+		return
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- event{name: name, data: encoded}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+const (
+	defaultStatsRangeDays = 7                // from・to を省略したときは今日までの 7 日間
+	sseKeepAlive          = 15 * time.Second // プロキシなどに切られないよう、この間隔でコメント行を送る
+	maxRequestBody        = 1 << 16
+)
+
+type statsTodayResponse struct {
+	Date     string `json:"date"`
+	RepCount int    `json:"repCount"`
+}
+
+type statsRangeResponse struct {
+	From     string               `json:"from"`
+	To       string               `json:"to"`
+	RepCount int                  `json:"repCount"`
+	Days     []statsTodayResponse `json:"days"`
+}
+
+// settingsResponse は判定しきい値と取り込み設定。API のトークンは含めない。
+type settingsResponse struct {
+	TopRatio      float64        `json:"topRatio"`
+	BottomRatio   float64        `json:"bottomRatio"`
+	CameraID      string         `json:"cameraId"`
+	CaptureWidth  int            `json:"captureWidth"`
+	CaptureHeight int            `json:"captureHeight"`
+	MaxFPS        float64        `json:"maxFps"`
+	Rotation      int            `json:"rotation"`
+	Mirror        bool           `json:"mirror"`
+	ROI           regionResponse `json:"roi"`
+}
+
+// regionResponse は検出範囲（フレームに対する比率）。幅か高さが 0 ならフレーム全体。
+type regionResponse struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type updateSettingsRequest struct {
+	TopRatio    float64 `json:"topRatio"`
+	BottomRatio float64 `json:"bottomRatio"`
+}
+
+type cameraResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type captureResponse struct {
+	Capturing bool   `json:"capturing"`
+	DeviceID  string `json:"deviceId,omitempty"`
+}
+
+type startCaptureRequest struct {
+	DeviceID string `json:"deviceId"`
+}
+
+func (s *Server) handleStatsToday(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	out, err := s.GetStatsInputPort.Execute(r.Context(), now)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, statsTodayResponse{Date: civil.DateOf(now).String(), RepCount: out.RepCount})
+}
+
+// handleStatsRange は from〜to（YYYY-MM-DD、両端を含む）の日ごとの rep 数を返す。
+func (s *Server) handleStatsRange(w http.ResponseWriter, r *http.Request) {
+	to := civil.DateOf(time.Now())
+	if v := r.URL.Query().Get("to"); v != "" {
+		d, err := civil.ParseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("to: %w", err))
+			return
+		}
+		to = d
+	}
+	from := to.AddDays(-(defaultStatsRangeDays - 1))
+	if v := r.URL.Query().Get("from"); v != "" {
+		d, err := civil.ParseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("from: %w", err))
+			return
+		}
+		from = d
+	}
+	out, err := s.GetStatsRangeInputPort.Execute(r.Context(), from, to)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	res := statsRangeResponse{From: from.String(), To: to.String(), RepCount: out.RepCount, Days: make([]statsTodayResponse, 0, len(out.Days))}
+	for _, day := range out.Days {
+		res.Days = append(res.Days, statsTodayResponse{Date: day.Date.String(), RepCount: day.RepCount})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	out, err := s.GetSettingInputPort.Execute(r.Context())
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, settingsResponse{
+		TopRatio:      out.TopRatio,
+		BottomRatio:   out.BottomRatio,
+		CameraID:      out.CameraID,
+		CaptureWidth:  out.CaptureWidth,
+		CaptureHeight: out.CaptureHeight,
+		MaxFPS:        out.MaxFPS,
+		Rotation:      out.Rotation,
+		Mirror:        out.Mirror,
+		ROI:           regionResponse{X: out.ROI.X, Y: out.ROI.Y, Width: out.ROI.Width, Height: out.ROI.Height},
+	})
+}
+
+// handleUpdateSettings は判定しきい値を更新する。検証はアプリの画面と同じ UpdateSetting のユースケースで行う。
+func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req updateSettingsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.UpdateSettingInputPort.Execute(r.Context(), req.TopRatio, req.BottomRatio); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	s.handleGetSettings(w, r)
+}
+
+// handleCameras はキャプチャできるカメラを返す。ブラウザのカメラはアプリの画面からしか使えないため含めない。
+func (s *Server) handleCameras(w http.ResponseWriter, r *http.Request) {
+	devices, err := s.Capture.ListCameras()
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	res := make([]cameraResponse, 0, len(devices))
+	for _, dev := range devices {
+		if !dev.Browser {
+			res = append(res, cameraResponse{ID: dev.ID, Name: dev.Name})
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleCapture(w http.ResponseWriter, r *http.Request) {
+	id := s.Capture.CaptureDeviceID()
+	writeJSON(w, http.StatusOK, captureResponse{Capturing: id != "", DeviceID: id})
+}
+
+func (s *Server) handleStartCapture(w http.ResponseWriter, r *http.Request) {
+	var req startCaptureRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.DeviceID == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("deviceId is required"))
+		return
+	}
+	if err := s.Capture.StartCapture(req.DeviceID); err != nil {
+		// 未接続のカメラや顔検出ヘルパーが使えないなど、いまは開始できない
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	s.handleCapture(w, r)
+}
+
+func (s *Server) handleStopCapture(w http.ResponseWriter, r *http.Request) {
+	s.Capture.StopCapture()
+	s.handleCapture(w, r)
+}
+
+// handleEvents は Publish されたイベントを、切断されるまで SSE（event: <name> / data: <JSON>）で送り続ける。
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case ev := <-ch:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// writeUsecaseError は入力値の検証エラーを 400、それ以外を 500 にする。
+func writeUsecaseError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errors.ErrInvalidArgument) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
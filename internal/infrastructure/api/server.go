@@ -0,0 +1,132 @@
+// Package api はダッシュボードやボットから使うローカル HTTP API（設定で有効にしたときだけ 127.0.0.1 で待ち受ける）。
+// 成績・判定しきい値・キャプチャの操作を JSON で、顔・スクワット・状態の変化を SSE（/api/events）で提供する。
+// すべてのリクエストに Authorization: Bearer <token> が必要（EventSource 向けに ?token= も受け付ける）。
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+// Capture は API から操作するキャプチャ（service.CameraService）。
+type Capture interface {
+	ListCameras() ([]service.CameraDevice, error)
+	StartCapture(deviceID string) error
+	StopCapture()
+	CaptureDeviceID() string
+}
+
+// Server はローカル HTTP API。Apply で設定を反映するまでは待ち受けない。
+type Server struct {
+	GetStatsInputPort      usecase.GetStatsInputPort
+	GetStatsRangeInputPort usecase.GetStatsRangeInputPort
+	GetSettingInputPort    usecase.GetSettingInputPort
+	UpdateSettingInputPort usecase.UpdateSettingInputPort
+	Capture                Capture
+
+	token  atomic.Pointer[string]
+	events broker
+
+	mu   sync.Mutex
+	srv  *http.Server
+	addr string
+}
+
+// Apply は設定を反映する。有効ならそのポートで待ち受け（ポートが変わったら開き直す）、無効なら止める。
+// トークンだけの変更は待ち受けたまま反映する。
+func (s *Server) Apply(conf entity.APISetting) error {
+	s.token.Store(&conf.Token)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	addr := fmt.Sprintf("127.0.0.1:%d", conf.Port)
+	if conf.Enabled && s.srv != nil && s.addr == addr {
+		return nil
+	}
+	s.closeLocked()
+	if !conf.Enabled {
+		return nil
+	}
+	if conf.Token == "" {
+		return fmt.Errorf("api: token is not set")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("api: listen %s: %w", addr, err)
+	}
+	srv := &http.Server{Handler: s.routes(), ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("api: %v", err)
+		}
+	}()
+	s.srv, s.addr = srv, addr
+	log.Printf("api: listening on http://%s", addr)
+	return nil
+}
+
+// Close は待ち受けを止め、SSE を含む接続を切る。
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *Server) closeLocked() {
+	if s.srv == nil {
+		return
+	}
+	if err := s.srv.Close(); err != nil {
+		log.Printf("api: close: %v", err)
+	}
+	s.srv, s.addr = nil, ""
+}
+
+// Publish は SSE の接続にイベントを送る。data は JSON にエンコードする。接続がなければ何もしない。
+func (s *Server) Publish(name string, data any) {
+	s.events.publish(name, data)
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/stats/today", s.handleStatsToday)
+	mux.HandleFunc("GET /api/stats", s.handleStatsRange)
+	mux.HandleFunc("GET /api/settings", s.handleGetSettings)
+	mux.HandleFunc("PUT /api/settings", s.handleUpdateSettings)
+	mux.HandleFunc("GET /api/cameras", s.handleCameras)
+	mux.HandleFunc("GET /api/capture", s.handleCapture)
+	mux.HandleFunc("POST /api/capture/start", s.handleStartCapture)
+	mux.HandleFunc("POST /api/capture/stop", s.handleStopCapture)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	return s.authenticate(mux)
+}
+
+// authenticate はトークンが一致しないリクエストを 401 にする。
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		want := s.token.Load()
+		if want == nil || *want == "" || subtle.ConstantTimeCompare([]byte(token), []byte(*want)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
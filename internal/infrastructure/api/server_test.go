@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/memory"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+)
+
+const testToken = "t0ken"
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	settings := memory.NewSettingRepository()
+	s := &Server{
+		GetSettingInputPort:    usecase.NewGetSettingUsecase(settings),
+		UpdateSettingInputPort: usecase.NewUpdateSettingUsecase(settings),
+	}
+	token := testToken
+	s.token.Store(&token)
+	return s
+}
+
+// freePort は空いているローカルのポートを返す。
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestServer_Authenticate(t *testing.T) {
+	srv := httptest.NewServer(newTestServer(t).routes())
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"no token", "/api/settings", "", http.StatusUnauthorized},
+		{"wrong bearer", "/api/settings", "Bearer wrong", http.StatusUnauthorized},
+		{"not a bearer", "/api/settings", testToken, http.StatusUnauthorized},
+		{"bearer", "/api/settings", "Bearer " + testToken, http.StatusOK},
+		{"query token", "/api/settings?token=" + testToken, "", http.StatusOK},
+		{"wrong query token", "/api/settings?token=wrong", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && res.Header.Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q, want Bearer", res.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestServer_UpdateSettings(t *testing.T) {
+	srv := httptest.NewServer(newTestServer(t).routes())
+	defer srv.Close()
+
+	put := func(body string) (int, map[string]any) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+"/api/settings", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		var got map[string]any
+		if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, got
+	}
+
+	// ユースケースの ErrInvalidArgument は 400 になる
+	for _, body := range []string{
+		`{"topRatio": 1.2, "bottomRatio": 0.5}`,
+		`{"topRatio": 0.5, "bottomRatio": 0}`,
+		`{"topRatio": 0.4, "bottomRatio": 0.6}`,
+		`{"topRatio": 0.6, "bottomRatio": 0.4, "token": "x"}`,
+	} {
+		if status, got := put(body); status != http.StatusBadRequest || got["error"] == "" {
+			t.Errorf("PUT %s = %d %v, want 400 with an error", body, status, got)
+		}
+	}
+
+	status, got := put(`{"topRatio": 0.6, "bottomRatio": 0.4}`)
+	if status != http.StatusOK || got["topRatio"] != 0.6 || got["bottomRatio"] != 0.4 {
+		t.Errorf("PUT valid ratios = %d %v, want 200 with the new ratios", status, got)
+	}
+}
+
+func TestServer_EventsStreamsPublished(t *testing.T) {
+	s := newTestServer(t)
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/api/events?token=" + testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	// ヘッダーが返った時点で購読済みなので、ここからの Publish は届く
+	s.Publish("rep", map[string]int{"todayCount": 3})
+
+	lines := make(chan string)
+	go func() {
+		sc := bufio.NewScanner(res.Body)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+	var got []string
+	for len(got) < 2 {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream ended after %q", got)
+			}
+			if line != "" {
+				got = append(got, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no SSE frame after Publish (got %q)", got)
+		}
+	}
+	if got[0] != "event: rep" || got[1] != `data: {"todayCount":3}` {
+		t.Errorf("SSE frame = %q, want the published rep event", got)
+	}
+}
+
+func TestServer_Apply(t *testing.T) {
+	s := newTestServer(t)
+	t.Cleanup(s.Close)
+
+	if err := s.Apply(entity.APISetting{Enabled: true, Port: freePort(t)}); err == nil {
+		t.Fatal("Apply enabled the API without a token")
+	}
+
+	get := func(port int) error {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/api/settings", port), nil)
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("status %d", res.StatusCode)
+		}
+		return nil
+	}
+
+	first := freePort(t)
+	if err := s.Apply(entity.APISetting{Enabled: true, Port: first, Token: testToken}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(first); err != nil {
+		t.Fatalf("GET on port %d: %v", first, err)
+	}
+
+	// ポートを変えたら新しいポートで開き直し、前のポートは閉じる
+	second := freePort(t)
+	if err := s.Apply(entity.APISetting{Enabled: true, Port: second, Token: testToken}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(second); err != nil {
+		t.Fatalf("GET on the new port %d: %v", second, err)
+	}
+	if err := get(first); err == nil {
+		t.Errorf("old port %d still answers after the port changed", first)
+	}
+
+	if err := s.Apply(entity.APISetting{Enabled: false, Port: second, Token: testToken}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(second); err == nil {
+		t.Errorf("port %d still answers after the API was disabled", second)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !server
+
 package app
 
 import (
@@ -6,23 +8,11 @@ import (
 
 	"github.com/kikils/desk-squat-tracker/internal/config"
 	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
-	dservice "github.com/kikils/desk-squat-tracker/internal/domain/service"
-	"github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service"
-	"github.com/kikils/desk-squat-tracker/internal/infrastructure/file"
-	"github.com/kikils/desk-squat-tracker/internal/infrastructure/memory"
 	"github.com/kikils/desk-squat-tracker/internal/infrastructure/python"
-	"github.com/kikils/desk-squat-tracker/internal/usecase"
 	"github.com/wailsapp/wails/v3/pkg/application"
 	"github.com/wailsapp/wails/v3/pkg/events"
 )
 
-func init() {
-	application.RegisterEvent[string]("time")
-	application.RegisterEvent[int]("squat")
-	application.RegisterEvent[*FaceViewModel]("face")
-	application.RegisterEvent[string]("cameraPreview")
-}
-
 func Run(assets fs.FS, iconStandup, iconSquat []byte) error {
 	// Create a new Wails application by providing the necessary options.
 	// Variables 'Name' and 'Description' are for application metadata.
@@ -30,40 +20,18 @@ func Run(assets fs.FS, iconStandup, iconSquat []byte) error {
 	// 'Bind' is a list of Go struct instances. The frontend has access to the methods of these instances.
 	// 'Mac' options tailor the application when running an macOS.
 
-	faceRepository, err := python.NewMediaPipeFaceRepository()
+	svcs, err := newServices()
 	if err != nil {
 		return err
 	}
 
-	judgementRepository := memory.NewJudgementRepository()
-	settingRepository, err := file.NewSettingRepository()
-	if err != nil {
-		return err
-	}
-	squatJudger := dservice.NewSquatJudger(faceRepository, judgementRepository, settingRepository)
-	cameraSvc := &service.CameraService{
-		InputPort: usecase.NewWatchSquatUsecase(faceRepository, judgementRepository, squatJudger),
-	}
-	statsSvc := &service.StatsService{
-		InputPort: usecase.NewGetStatsUsecase(judgementRepository),
-	}
-	settingsSvc := &service.SettingsService{
-		GetSettingInputPort:    usecase.NewGetSettingUsecase(settingRepository),
-		UpdateSettingInputPort: usecase.NewUpdateSettingUsecase(settingRepository),
-	}
-
 	app := application.New(application.Options{
 		Name:        config.AppName,
 		Description: "A demo of using raw HTML & CSS",
-		Services: []application.Service{
-			application.NewService(&service.GreetService{}),
-			application.NewService(&service.AppService{}),
-			application.NewService(cameraSvc),
-			application.NewService(statsSvc),
-			application.NewService(settingsSvc),
-		},
+		Services:    svcs.bind(),
 		Assets: application.AssetOptions{
-			Handler: application.AssetFileServerFS(assets),
+			Handler:    application.AssetFileServerFS(assets),
+			Middleware: svcs.preview.Middleware,
 		},
 		Mac: application.MacOptions{
 			// トレイに格納した状態でクリックしても終了しないよう false にする。
@@ -74,29 +42,19 @@ func Run(assets fs.FS, iconStandup, iconSquat []byte) error {
 	})
 
 	app.OnShutdown(python.StopFaceDetectServer)
+	app.OnShutdown(svcs.mqtt.Close)
 
 	systray := app.SystemTray.New()
 	systray.SetIcon(iconStandup)
 
-	cameraSvc.OnResult = func(out *usecase.WatchSquatOutput) {
-		if vm := FaceViewModelFrom(out); vm != nil {
-			app.Event.Emit("face", vm)
-		}
-		if out != nil && out.Judgement != nil {
-			if out.Judgement.IsRepCompleted {
-				app.Event.Emit("squat", 0)
-			}
-			// 判定状態に応じてトレイアイコンを切り替え（立っている→standup、しゃがんでいる→squat）
-			if out.Judgement.State == entity.DetectStateStanding {
-				systray.SetIcon(iconStandup)
-			} else {
-				systray.SetIcon(iconSquat)
-			}
+	svcs.emitEvents(app, func(judgement *entity.Judgement) {
+		// 判定状態に応じてトレイアイコンを切り替え（立っている→standup、しゃがんでいる→squat）
+		if judgement.State == entity.DetectStateStanding {
+			systray.SetIcon(iconStandup)
+		} else {
+			systray.SetIcon(iconSquat)
 		}
-	}
-	cameraSvc.OnPreview = func(dataURL string) {
-		app.Event.Emit("cameraPreview", dataURL)
-	}
+	})
 
 	popupWindow := app.Window.NewWithOptions(application.WebviewWindowOptions{
 		Width:           400,
@@ -116,6 +74,14 @@ func Run(assets fs.FS, iconStandup, iconSquat []byte) error {
 		URL:              "/",
 	})
 
+	// ウィンドウが隠れている間（HideOnFocusLost など）はプレビューを作らない
+	popupWindow.OnWindowEvent(events.Common.WindowHide, func(*application.WindowEvent) {
+		svcs.preview.SetHidden(true)
+	})
+	popupWindow.OnWindowEvent(events.Common.WindowShow, func(*application.WindowEvent) {
+		svcs.preview.SetHidden(false)
+	})
+
 	popupWindow.RegisterHook(events.Common.WindowClosing, func(event *application.WindowEvent) {
 		popupWindow.Hide()
 		event.Cancel()
@@ -133,7 +99,7 @@ func Run(assets fs.FS, iconStandup, iconSquat []byte) error {
 		}
 	}()
 
-	if err := python.StartFaceDetectServer(app.Context()); err != nil {
+	if err := svcs.startHelper(app.Context()); err != nil {
 		return err
 	}
 
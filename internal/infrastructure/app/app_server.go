@@ -0,0 +1,68 @@
+//go:build server
+
+package app
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+
+	"github.com/kikils/desk-squat-tracker/internal/config"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/file"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/python"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/serverauth"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// Run はトレイやウィンドウを作らず、フロントとサービスのバインディングを HTTP で配信するサーバーとして起動する。
+// イベントは /wails/events の WebSocket で各ブラウザに届く。ネットワークカメラ（CAMERA_NETWORKURLS）と組み合わせ、
+// ディスプレイのない小型 PC で動かすことを想定している。アイコンはトレイがないため使わない。
+func Run(assets fs.FS, _, _ []byte) error {
+	svcs, err := newServices()
+	if err != nil {
+		return err
+	}
+	svcs.app.Headless = true
+
+	serverConf := config.Get().Server
+	// バインディングは設定（API のトークンなど）の読み書きやキャプチャの操作ができるので、トークンを持つブラウザにだけ開く
+	token := serverConf.Token
+	if token == "" {
+		if token, err = file.ServerToken(); err != nil {
+			return err
+		}
+	}
+	guard := serverauth.NewGuard(token)
+	app := application.New(application.Options{
+		Name:        config.AppName,
+		Description: "A demo of using raw HTML & CSS",
+		Services:    svcs.bind(),
+		Assets: application.AssetOptions{
+			Handler: application.AssetFileServerFS(assets),
+			Middleware: func(next http.Handler) http.Handler {
+				return guard.Middleware(svcs.preview.Middleware(next))
+			},
+		},
+		Server: application.ServerOptions{
+			Host: serverConf.Host,
+			Port: serverConf.Port,
+		},
+	})
+
+	app.OnShutdown(python.StopFaceDetectServer)
+	app.OnShutdown(svcs.mqtt.Close)
+
+	svcs.emitEvents(app, nil)
+	log.Printf("server: open http://%s:%d/?token=%s", serverConf.Host, serverConf.Port, token)
+
+	if err := svcs.startHelper(app.Context()); err != nil {
+		return err
+	}
+
+	// SIGINT / SIGTERM を受けるか Quit が呼ばれるまで戻らない
+	err = app.Run()
+	// サーバーモードではシグナルで止めたときに OnShutdown とサービスの終了処理が呼ばれないので、ここで呼ぶ
+	// （Quit で止めたときは終了処理が済んでいるため何もしない）
+	app.Quit()
+	return err
+}
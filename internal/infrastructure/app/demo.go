@@ -0,0 +1,27 @@
+package app
+
+import (
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/config"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/camera"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/simulator"
+)
+
+// newDemo はデモモード用に、同じシミュレーターを共有する FaceRepository と FrameSource を返す。
+func newDemo(conf config.Demo) (repository.FaceRepository, camera.FrameSource) {
+	sim := simulator.New(simulator.Params{
+		FrameWidth:       conf.FrameWidth,
+		FrameHeight:      conf.FrameHeight,
+		Tempo:            conf.Tempo,
+		Rest:             conf.Rest,
+		Depth:            conf.Depth,
+		Jitter:           conf.Jitter,
+		DropoutRate:      conf.DropoutRate,
+		PasserbyInterval: conf.PasserbyInterval,
+		PasserbyDuration: conf.PasserbyDuration,
+		Seed:             conf.Seed,
+	}, time.Now())
+	return simulator.NewFaceRepository(sim), simulator.NewFrameSource(sim, conf.FPS)
+}
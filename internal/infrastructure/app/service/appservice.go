@@ -2,7 +2,25 @@ package service
 
 import "github.com/wailsapp/wails/v3/pkg/application"
 
-type AppService struct{}
+type AppService struct {
+	// HelperError は顔検出ヘルパーを起動できなかった理由（整合性チェックの失敗など）。nil なら正常。
+	HelperError error
+	// Headless はウィンドウを持たないサーバーとして起動しているか（-tags server）。
+	Headless bool
+}
+
+// GetHelperError は顔検出ヘルパーの起動エラーを返す。正常に起動していれば空文字。
+func (s *AppService) GetHelperError() string {
+	if s.HelperError == nil {
+		return ""
+	}
+	return s.HelperError.Error()
+}
+
+// IsHeadless はサーバーとして起動しているかを返す。フロントはこのときアプリの終了ボタンを出さない。
+func (s *AppService) IsHeadless() bool {
+	return s.Headless
+}
 
 // Quit はアプリ全体を終了します（トレイ常駐も含めてプロセスが終了します）。
 // バインディングの応答が WebView に返る前に同期で Quit するとデッドロックするため、
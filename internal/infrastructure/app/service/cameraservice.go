@@ -1,61 +1,185 @@
 package service
 
 import (
+	"bytes"
 	"context"
-	"encoding/base64"
+	"fmt"
+	"image/jpeg"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
 	"github.com/kikils/desk-squat-tracker/internal/infrastructure/camera"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/session"
 	"github.com/kikils/desk-squat-tracker/internal/usecase"
 	"github.com/kikils/desk-squat-tracker/internal/utils"
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
+// BrowserCameraID は WebView の getUserMedia で取得したカメラを表すデバイス ID。
+// このデバイスはフロントエンドがフレームを PushFrame で送るため、StartCapture は選択を保存するだけで何も開かない。
+const BrowserCameraID = "browser"
+
+const (
+	jpegQuality           = 75 // 検出に渡す JPEG の品質
+	defaultPreviewFPS     = 5
+	defaultPreviewQuality = 75
+
+	stallTimeout        = 5 * time.Second // この間フレームが来なければ途絶とみなす
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// cameraStatus イベントで通知するキャプチャの状態。
 const (
-	jpegQuality   = 75
-	previewFPS    = 5
-	previewPeriod = time.Second / previewFPS
+	CameraStateActive       = "active"       // フレームを受信している
+	CameraStateStalled      = "stalled"      // 一定時間フレームが来ない
+	CameraStateDisconnected = "disconnected" // デバイスからのストリームが終了した
+	CameraStateReconnecting = "reconnecting" // 同じデバイスを開き直している
+	CameraStateEnded        = "ended"        // 録画ファイルなどが終端に達した
+	CameraStateStopped      = "stopped"      // StopCapture などでキャプチャを止めた
 )
 
+// CameraStatus はフロントに通知するキャプチャの状態。
+type CameraStatus struct {
+	State      string `json:"state"`
+	DeviceID   string `json:"deviceId"`
+	DeviceName string `json:"deviceName"`
+	Attempt    int    `json:"attempt,omitempty"` // 再接続の試行回数
+	Error      string `json:"error,omitempty"`   // 直前の再接続の失敗理由
+}
+
 // CameraDevice はフロントに渡すカメラ情報。
 type CameraDevice struct {
-	Index int    `json:"Index"`
-	Name  string `json:"Name"`
+	ID      string `json:"ID"`
+	Name    string `json:"Name"`
+	Browser bool   `json:"Browser"` // true ならフロントエンドで取得し PushFrame で送るカメラ
 }
 
 type CameraService struct {
+	Source    camera.FrameSource
 	InputPort usecase.WatchSquatInputPort
 	OnResult  func(*usecase.WatchSquatOutput)
-	OnPreview func(dataURL string)
+	OnStatus  func(CameraStatus)
+	// OnPreview はプレビュー用の JPEG（フレーム全体）を受け取る。jpeg は呼び出しの間だけ有効。
+	// PreviewWanted が false を返す間はエンコード自体を行わない（nil なら常に送る）。
+	OnPreview      func(jpeg []byte)
+	PreviewWanted  func() bool
+	PreviewFPS     float64 // 0 なら defaultPreviewFPS
+	PreviewQuality int     // 0 なら defaultPreviewQuality
+	// PreviewPath はプレビューを配信するエンドポイント（トークン付き）。PreviewURL でフロントに渡す。
+	PreviewPath string
+	// DetectorError が非 nil の間は顔検出が使えないため、キャプチャを開始せずにこのエラーを返す。
+	DetectorError error
+	// NewRecorder が設定されていれば、キャプチャごとにセッションを記録する。
+	NewRecorder func() (*session.Recorder, error)
+	// ROI は顔検出の対象にする範囲を返す。nil ならフレーム全体を使う。
+	ROI func() entity.Region
+	// PushTransform は PushFrame で受け取ったフレームに、ネイティブのソースと同じ回転・解像度・間引きを適用する。
+	// 間引くフレームでは false を返す。nil なら変換しない。
+	PushTransform func(camera.Frame) (camera.Frame, bool)
+	// OnLighting は暗すぎる・逆光などの撮影条件を間引いて通知する。
+	// CorrectLighting が true なら、条件が悪い間は検出前に明るさを補正する。
+	OnLighting      func(LightingWarning)
+	CorrectLighting bool
+	LightingRepeat  time.Duration
+	// DetectionRate は動きに応じて顔検出の頻度を落とす設定。ゼロ値なら全フレームを検出する。
+	DetectionRate DetectionRate
+	// Tracking は前回の顔の周りだけを切り抜いて検出する設定。ゼロ値なら毎回 ROI 全体から探す。
+	Tracking Tracking
+	// 選択したカメラの保存と、起動時に前回のカメラを開くために使う。nil なら記憶しない。
+	GetSettingInputPort   usecase.GetSettingInputPort
+	SelectCameraInputPort usecase.SelectCameraInputPort
 
 	ctx context.Context
 
 	mu            sync.Mutex
 	captureCancel context.CancelFunc
 	captureGen    int // incremented per StartCapture; used to avoid clearing a newer capture's cancel
+	captureID     string
+
+	pushBusy   atomic.Bool // PushFrame の処理中は true。処理中に届いたフレームは捨てる
+	detectRate atomic.Pointer[detectRateController]
 }
 
 func (s *CameraService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
 	s.ctx = ctx
+	go s.startRememberedCamera()
 	return nil
 }
 
-// ListCameras は利用可能なカメラ一覧を返す。
+// startRememberedCamera は前回選んだカメラが接続されていれば、そのカメラでキャプチャを開始する。
+// 見つからない・開けない場合はログに残すだけで、ユーザーがカメラを選ぶのを待つ。
+func (s *CameraService) startRememberedCamera() {
+	if s.GetSettingInputPort == nil || s.DetectorError != nil {
+		return
+	}
+	setting, err := s.GetSettingInputPort.Execute(s.ctx)
+	if err != nil {
+		log.Printf("camera: load remembered camera: %v", err)
+		return
+	}
+	if setting.CameraID == "" || setting.CameraID == BrowserCameraID {
+		return
+	}
+	if err := s.StartCapture(setting.CameraID); err != nil {
+		log.Printf("camera: remembered camera %q is not available: %v", setting.CameraID, err)
+	}
+}
+
+// PreviewURL はカメラのプレビューを取得する URL を返す。after=<X-Preview-Seq> を付けると次のフレームまで待つ。
+// 配信していなければ空文字。
+func (s *CameraService) PreviewURL() string {
+	return s.PreviewPath
+}
+
+// previewPeriod はプレビューを送る間隔。
+func (s *CameraService) previewPeriod() time.Duration {
+	fps := s.PreviewFPS
+	if fps <= 0 {
+		fps = defaultPreviewFPS
+	}
+	return time.Duration(float64(time.Second) / fps)
+}
+
+func (s *CameraService) previewQuality() int {
+	if s.PreviewQuality <= 0 || s.PreviewQuality > 100 {
+		return defaultPreviewQuality
+	}
+	return s.PreviewQuality
+}
+
+// previewWanted はプレビューを見ている画面があるかを返す。
+func (s *CameraService) previewWanted() bool {
+	return s.OnPreview != nil && (s.PreviewWanted == nil || s.PreviewWanted())
+}
+
+// ListCameras は利用可能なカメラ一覧を返す。末尾には常に WebView のカメラ（BrowserCameraID）を含める。
 func (s *CameraService) ListCameras() ([]CameraDevice, error) {
-	devs, err := camera.ListDevices()
+	browser := CameraDevice{ID: BrowserCameraID, Name: "ブラウザのカメラ", Browser: true}
+	devs, err := s.Source.ListDevices()
 	if err != nil || len(devs) == 0 {
-		return []CameraDevice{{Index: 0, Name: "デフォルトカメラ"}}, nil
+		return []CameraDevice{{ID: "default", Name: "デフォルトカメラ"}, browser}, nil
 	}
-	out := make([]CameraDevice, len(devs))
+	out := make([]CameraDevice, 0, len(devs)+1)
 	for i := range devs {
-		out[i] = CameraDevice{Index: devs[i].Index, Name: devs[i].Name}
+		out = append(out, CameraDevice{ID: devs[i].ID, Name: devs[i].Name})
 	}
-	return out, nil
+	return append(out, browser), nil
 }
 
-// StartCapture は指定したデバイスインデックスでキャプチャを開始する。macOS では AVFoundation で選択。
-func (s *CameraService) StartCapture(deviceIndex int) error {
+// StartCapture は指定した ID のデバイスから Source でキャプチャを開始し、選んだカメラとして保存する。
+// フレームが途絶えたり切断されたりした場合は、同じデバイスを探してバックオフしながら開き直す。
+func (s *CameraService) StartCapture(deviceID string) error {
+	if s.DetectorError != nil {
+		return s.DetectorError
+	}
+	if deviceID == BrowserCameraID {
+		s.rememberCamera(deviceID)
+		return nil
+	}
 	s.mu.Lock()
 	if s.captureCancel != nil {
 		s.mu.Unlock()
@@ -67,7 +191,7 @@ func (s *CameraService) StartCapture(deviceIndex int) error {
 	s.captureCancel = cancel
 	s.mu.Unlock()
 
-	frames, err := camera.StartStream(ctx, deviceIndex)
+	dev, stream, err := s.openDevice(ctx, camera.Device{ID: deviceID})
 	if err != nil {
 		cancel()
 		s.mu.Lock()
@@ -75,50 +199,228 @@ func (s *CameraService) StartCapture(deviceIndex int) error {
 		s.mu.Unlock()
 		return err
 	}
+	s.mu.Lock()
+	s.captureID = dev.ID
+	s.mu.Unlock()
+	s.rememberCamera(dev.ID)
 
+	var recorder *session.Recorder
+	if s.NewRecorder != nil {
+		if recorder, err = s.NewRecorder(); err != nil {
+			log.Printf("camera: session recorder disabled: %v", err)
+		} else {
+			log.Printf("camera: recording session to %s", recorder.Path())
+		}
+	}
+
+	s.emitStatus(CameraStatus{State: CameraStateActive}, dev)
 	go func() {
 		defer func() {
 			s.mu.Lock()
 			if s.captureGen == gen {
 				s.captureCancel = nil
+				s.captureID = ""
 			}
 			s.mu.Unlock()
 		}()
-		lastPreview := time.Time{}
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case f, ok := <-frames:
-				if !ok {
-					return
-				}
-				jpegBytes, err := utils.EncodeJPEG(utils.Frame{Data: f.Data, Width: f.Width, Height: f.Height}, jpegQuality)
-				if err != nil || len(jpegBytes) == 0 {
-					continue
-				}
-				if s.OnPreview != nil && time.Since(lastPreview) >= previewPeriod {
-					lastPreview = time.Now()
-					dataURL := "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(jpegBytes)
-					s.OnPreview(dataURL)
-				}
-				out, err := s.InputPort.Execute(ctx, jpegBytes, time.Now())
-				if err != nil {
-					continue
-				}
-				if s.OnResult != nil && out != nil && out.Face != nil && out.Judgement != nil {
-					s.OnResult(out)
-				}
-			}
+		if recorder != nil {
+			defer recorder.Close()
 		}
+		pipeline := s.startPipeline(ctx, dev.Live, recorder)
+		state := s.watch(ctx, dev, stream, pipeline)
+		// 残りのフレームの判定が終わってから停止・終端を通知する
+		pipeline.stop()
+		s.emitStatus(CameraStatus{State: state}, dev)
 	}()
 	return nil
 }
 
+// watch はストリームのフレームを pipeline に流し、途絶・切断を検知したら開き直す。
+// ctx が終わるかファイルが終端に達するまで戻らない。戻り値は最後の状態（stopped か ended）。
+func (s *CameraService) watch(ctx context.Context, dev camera.Device, stream camera.Stream, pipeline *detectPipeline) string {
+	for {
+		state := s.consume(ctx, dev, stream, pipeline)
+		stream.Close()
+		if ctx.Err() != nil {
+			return CameraStateStopped
+		}
+		if !dev.Live {
+			return CameraStateEnded
+		}
+		log.Printf("camera: %s (%s), reconnecting", state, dev.Name)
+		s.emitStatus(CameraStatus{State: state}, dev)
+		if stream = s.reopen(ctx, dev); stream == nil {
+			return CameraStateStopped
+		}
+		log.Printf("camera: reconnected (%s)", dev.Name)
+		s.emitStatus(CameraStatus{State: CameraStateActive}, dev)
+	}
+}
+
+// consume はストリームのフレームを検出・判定に流す。戻り値はストリームを抜けた理由。
+// 検出を待たずに受け取り続けるため、途絶の検知が検出の遅さに左右されない。
+func (s *CameraService) consume(ctx context.Context, dev camera.Device, stream camera.Stream, pipeline *detectPipeline) string {
+	frames := stream.Frames()
+	stall := time.NewTimer(stallTimeout)
+	defer stall.Stop()
+	if !dev.Live {
+		// 録画ファイルは処理が追いつかない間フレームが来ないだけなので、途絶とはみなさない
+		stall.Stop()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return CameraStateStopped
+		case <-stall.C:
+			return CameraStateStalled
+		case f, ok := <-frames:
+			if !ok {
+				return CameraStateDisconnected
+			}
+			if dev.Live {
+				stall.Reset(stallTimeout)
+			}
+			pipeline.push(f)
+		}
+	}
+}
+
+func (s *CameraService) roi() entity.Region {
+	if s.ROI == nil {
+		return entity.Region{}
+	}
+	return s.ROI()
+}
+
+// reopen は dev と同じデバイスが開けるまでバックオフしながら試す。ctx が終わった場合は nil を返す。
+func (s *CameraService) reopen(ctx context.Context, dev camera.Device) camera.Stream {
+	backoff := reconnectMinBackoff
+	for attempt := 1; ; attempt++ {
+		s.emitStatus(CameraStatus{State: CameraStateReconnecting, Attempt: attempt}, dev)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		_, stream, err := s.openDevice(ctx, dev)
+		if err == nil {
+			return stream
+		}
+		log.Printf("camera: reconnect attempt %d failed: %v", attempt, err)
+		s.emitStatus(CameraStatus{State: CameraStateReconnecting, Attempt: attempt, Error: err.Error()}, dev)
+		backoff = min(backoff*2, reconnectMaxBackoff)
+	}
+}
+
+// openDevice は現在のデバイス一覧から dev を探して開く。ID があれば ID で、なければ名前で照合する。
+// インデックスは抜き差しでずれるため、開く直前の一覧から引き直す。
+func (s *CameraService) openDevice(ctx context.Context, dev camera.Device) (camera.Device, camera.Stream, error) {
+	devs, err := s.Source.ListDevices()
+	if err != nil {
+		return dev, nil, err
+	}
+	for _, d := range devs {
+		if (dev.ID != "" && d.ID == dev.ID) || (dev.ID == "" && d.Name == dev.Name) {
+			stream, err := s.Source.Open(ctx, d.Index)
+			return d, stream, err
+		}
+	}
+	name := dev.Name
+	if name == "" {
+		name = dev.ID
+	}
+	return dev, nil, fmt.Errorf("camera: device %q is not connected", name)
+}
+
+// rememberCamera は次回起動時に同じカメラを開けるよう、選んだカメラの ID を保存する。
+func (s *CameraService) rememberCamera(deviceID string) {
+	if s.SelectCameraInputPort == nil || deviceID == "" {
+		return
+	}
+	if err := s.SelectCameraInputPort.Execute(s.ctx, deviceID); err != nil {
+		log.Printf("camera: remember selected camera: %v", err)
+	}
+}
+
+func (s *CameraService) emitStatus(status CameraStatus, dev camera.Device) {
+	if s.OnStatus == nil {
+		return
+	}
+	status.DeviceID = dev.ID
+	status.DeviceName = dev.Name
+	s.OnStatus(status)
+}
+
+// PushFrame はフロントエンドが getUserMedia で取得した JPEG フレームを顔検出・判定に流す。
+// 前のフレームを処理中であれば検出せずに捨て、false を返す（フロントエンドは次のフレームを送ればよい）。
+func (s *CameraService) PushFrame(jpegBytes []byte) (bool, error) {
+	if s.DetectorError != nil {
+		return false, s.DetectorError
+	}
+	s.mu.Lock()
+	capturing := s.captureCancel != nil
+	s.mu.Unlock()
+	if capturing {
+		return false, fmt.Errorf("camera: capture from a native source is active; stop it before pushing frames")
+	}
+	if len(jpegBytes) == 0 {
+		return false, fmt.Errorf("camera: empty frame")
+	}
+	if !s.pushBusy.CompareAndSwap(false, true) {
+		return false, nil
+	}
+	defer s.pushBusy.Store(false)
+
+	in := &usecase.WatchSquatInput{Frame: jpegBytes, Timestamp: time.Now()}
+	if roi := s.roi(); s.PushTransform != nil || !roi.IsFull() {
+		// 変換か ROI がある場合だけデコードし、ネイティブのソースと同じく変換後のフレームを切り抜く
+		img, err := jpeg.Decode(bytes.NewReader(jpegBytes))
+		if err != nil {
+			return false, fmt.Errorf("camera: decode pushed frame: %w", err)
+		}
+		f := camera.FrameFromImage(img)
+		f.Timestamp = in.Timestamp
+		if s.PushTransform != nil {
+			var ok bool
+			if f, ok = s.PushTransform(f); !ok {
+				return false, nil
+			}
+		}
+		encoded := f
+		if !roi.IsFull() {
+			rect := roi.Rect(f.Width, f.Height)
+			encoded = f.Crop(rect.X, rect.Y, rect.Width, rect.Height)
+			f.Release()
+			in.Crop, in.FrameWidth, in.FrameHeight = &rect, f.Width, f.Height
+		}
+		in.Frame, err = utils.EncodeJPEG(utils.Frame{Data: encoded.Data, Width: encoded.Width, Height: encoded.Height}, jpegQuality)
+		encoded.Release()
+		if err != nil {
+			return false, err
+		}
+	}
+	out, err := s.InputPort.Execute(s.ctx, in)
+	if err != nil {
+		return false, err
+	}
+	if s.OnResult != nil && out.Face != nil && out.Judgement != nil {
+		s.OnResult(out)
+	}
+	return true, nil
+}
+
+// CaptureDeviceID はキャプチャ中のデバイスの ID を返す。キャプチャしていなければ空文字。
+func (s *CameraService) CaptureDeviceID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.captureID
+}
+
 func (s *CameraService) StopCapture() {
 	s.mu.Lock()
 	cancel := s.captureCancel
 	s.captureCancel = nil
+	s.captureID = ""
 	s.mu.Unlock()
 	if cancel != nil {
 		cancel()
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/camera"
+)
+
+// 検出頻度のモード。
+const (
+	DetectModeFull   = "full"   // すべてのフレーム（録画ファイル、または適応制御なし）
+	DetectModeIdle   = "idle"   // 立っていて動きがないので頻度を落としている
+	DetectModeActive = "active" // 動き始めた・スクワット中なので ActiveFPS で検出している
+)
+
+// DetectionRate は動きに応じた顔検出の頻度の設定。IdleFPS が 0 なら常に全フレームを検出する。
+type DetectionRate struct {
+	IdleFPS       float64       // 立位で動きがない間の頻度
+	ActiveFPS     float64       // 動いている・スクワット中の頻度（0 なら上限なし）
+	VerticalSpeed float64       // 上下の動きがこれ以上（フレームの高さ / 秒）なら ActiveFPS にする
+	ChangeRatio   float64       // 変化した画素の割合がこれ以上なら ActiveFPS にする（人の出入りなど）
+	Hold          time.Duration // 動きが止まってから IdleFPS に戻すまでの時間
+}
+
+// CameraDiagnostics は検出頻度の診断情報。
+type CameraDiagnostics struct {
+	Capturing     bool    `json:"capturing"`
+	Mode          string  `json:"mode"`
+	TargetFPS     float64 `json:"targetFps"` // 0 なら上限なし
+	DetectFPS     float64 `json:"detectFps"` // 直近で実際に検出した頻度
+	IdleFPS       float64 `json:"idleFps"`
+	ActiveFPS     float64 `json:"activeFps"`
+	Changed       float64 `json:"changed"`       // 直近のフレームで変化した画素の割合
+	VerticalSpeed float64 `json:"verticalSpeed"` // 直近の上下の動き（フレームの高さ / 秒）
+	JudgeState    string  `json:"judgeState"`
+}
+
+const detectRateWindow = 10 // 実際の検出頻度を求めるのに使う直近の検出回数
+
+// detectRateController はフレーム差分の動きと判定の状態から、次に検出するかを決める。
+// 前処理の段（動き・検出の間引き）と判定の段（状態）から呼ばれるため、mu で保護する。
+type detectRateController struct {
+	rate DetectionRate
+	live bool
+
+	mu          sync.Mutex
+	motion      camera.MotionEstimator
+	lastFrame   time.Time
+	lastMotion  camera.Motion
+	speed       float64
+	movedAt     time.Time // 最後に動きを検知した時刻
+	lastDetect  time.Time
+	judgeState  entity.DetectState
+	detectTimes []time.Time
+}
+
+func newDetectRateController(rate DetectionRate, live bool) *detectRateController {
+	return &detectRateController{rate: rate, live: live}
+}
+
+func (c *detectRateController) adaptive() bool {
+	return c.live && c.rate.IdleFPS > 0
+}
+
+// observe は前処理の段で毎フレーム呼び、動きを更新してこのフレームを検出に回すかを返す。
+func (c *detectRateController) observe(f camera.Frame, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.adaptive() {
+		return true
+	}
+	m := c.motion.Update(f)
+	if !c.lastFrame.IsZero() {
+		if dt := now.Sub(c.lastFrame).Seconds(); dt > 0 {
+			c.speed = math.Abs(m.Vertical) / dt
+		}
+	}
+	c.lastFrame, c.lastMotion = now, m
+	if (c.rate.VerticalSpeed > 0 && c.speed >= c.rate.VerticalSpeed) || (c.rate.ChangeRatio > 0 && m.Changed >= c.rate.ChangeRatio) {
+		c.movedAt = now
+	}
+	fps := c.targetFPS(now)
+	if fps > 0 && !c.lastDetect.IsZero() && now.Sub(c.lastDetect) < time.Duration(float64(time.Second)/fps) {
+		return false
+	}
+	c.lastDetect = now
+	return true
+}
+
+// detected は判定の段で検出結果ごとに呼ぶ。judgement が nil（顔なし・検出失敗）なら判定の状態は変えない。
+func (c *detectRateController) detected(judgement *entity.Judgement, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if judgement != nil {
+		c.judgeState = judgement.State
+	}
+	c.detectTimes = append(c.detectTimes, now)
+	if len(c.detectTimes) > detectRateWindow {
+		c.detectTimes = c.detectTimes[len(c.detectTimes)-detectRateWindow:]
+	}
+}
+
+// mode は mu を持った状態で呼ぶ。
+func (c *detectRateController) mode(now time.Time) string {
+	if !c.adaptive() {
+		return DetectModeFull
+	}
+	switch c.judgeState {
+	case entity.DetectStateGoingDown, entity.DetectStateBottom, entity.DetectStateGoingUp:
+		// スクワットの途中は取りこぼさないよう常に全速
+		return DetectModeActive
+	}
+	if !c.movedAt.IsZero() && now.Sub(c.movedAt) < c.rate.Hold {
+		return DetectModeActive
+	}
+	return DetectModeIdle
+}
+
+// targetFPS は mu を持った状態で呼ぶ。
+func (c *detectRateController) targetFPS(now time.Time) float64 {
+	switch c.mode(now) {
+	case DetectModeIdle:
+		return c.rate.IdleFPS
+	case DetectModeActive:
+		return c.rate.ActiveFPS
+	}
+	return 0
+}
+
+func (c *detectRateController) diagnostics(now time.Time) CameraDiagnostics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d := CameraDiagnostics{
+		Capturing:     true,
+		Mode:          c.mode(now),
+		TargetFPS:     c.targetFPS(now),
+		IdleFPS:       c.rate.IdleFPS,
+		ActiveFPS:     c.rate.ActiveFPS,
+		Changed:       c.lastMotion.Changed,
+		VerticalSpeed: c.speed,
+		JudgeState:    c.judgeState.String(),
+	}
+	if n := len(c.detectTimes); n >= 2 {
+		if span := c.detectTimes[n-1].Sub(c.detectTimes[0]).Seconds(); span > 0 {
+			d.DetectFPS = float64(n-1) / span
+		}
+	}
+	return d
+}
+
+// Diagnostics はキャプチャ中の検出頻度と動きの大きさを返す。キャプチャしていなければ Capturing が false。
+func (s *CameraService) Diagnostics() CameraDiagnostics {
+	if c := s.detectRate.Load(); c != nil {
+		return c.diagnostics(time.Now())
+	}
+	return CameraDiagnostics{IdleFPS: s.DetectionRate.IdleFPS, ActiveFPS: s.DetectionRate.ActiveFPS}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/camera"
+)
+
+// lightingStableFor は撮影条件がこの間続いてから通知する（一瞬の明暗で通知が揺れないようにする）。
+const lightingStableFor = 2 * time.Second
+
+// LightingWarning はフロントに通知する撮影条件。Condition が "ok" なら警告の解除。
+type LightingWarning struct {
+	Condition    string  `json:"condition"`
+	Mean         float64 `json:"mean"`
+	CenterMean   float64 `json:"centerMean"`
+	SurroundMean float64 `json:"surroundMean"`
+	Corrected    bool    `json:"corrected"` // 検出前に明るさを補正しているか
+}
+
+// lightingMonitor は撮影条件の変化を間引いて通知するかを決める。
+type lightingMonitor struct {
+	repeat     time.Duration // 悪い条件が続く間、この間隔で再通知する
+	candidate  camera.LightingCondition
+	since      time.Time
+	notified   camera.LightingCondition
+	notifiedAt time.Time
+}
+
+func newLightingMonitor(repeat time.Duration) *lightingMonitor {
+	return &lightingMonitor{repeat: repeat, notified: camera.LightingOK}
+}
+
+// observe は now 時点の条件を記録し、通知すべきなら true を返す。
+func (m *lightingMonitor) observe(c camera.LightingCondition, now time.Time) bool {
+	if c != m.candidate {
+		m.candidate = c
+		m.since = now
+	}
+	if now.Sub(m.since) < lightingStableFor {
+		return false
+	}
+	if c == m.notified && (c == camera.LightingOK || m.repeat <= 0 || now.Sub(m.notifiedAt) < m.repeat) {
+		return false
+	}
+	m.notified = c
+	m.notifiedAt = now
+	return true
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/camera"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/session"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+	"github.com/kikils/desk-squat-tracker/internal/utils"
+)
+
+const (
+	judgeQueueSize = 4 // 判定は速いので、検出結果は捨てずにこの数まで溜める
+)
+
+// jpegBufPool は検出に渡す JPEG の置き場。前処理のエンコーダは次のフレームで上書きされるため、
+// 検出・判定が終わるまではここにコピーしたものを使う。
+var jpegBufPool = sync.Pool{New: func() any { b := make([]byte, 0, 64<<10); return &b }}
+
+// detectPipeline は 1 回のキャプチャのフレームを 前処理 → 検出 → 判定 の段に分けて並行に処理する。
+// 段の間は 1 枠のチャネルでつなぎ、後段が処理中に届いたフレームは古い方を捨てて最新のものに置き換える。
+// これにより検出の往復時間がキャプチャやプレビューを遅らせず、検出には常に最新のフレームが渡る。
+// 録画ファイルなど Live でないソースでは捨てずに後段を待ち、すべてのフレームを判定する。
+type detectPipeline struct {
+	s        *CameraService
+	ctx      context.Context
+	live     bool
+	recorder *session.Recorder
+
+	frames  chan capturedFrame // キャプチャ → 前処理
+	jobs    chan *detectJob    // 前処理 → 検出
+	results chan *detectJob    // 検出 → 判定
+	done    chan struct{}      // 判定の段が終わったら閉じる
+
+	rate    *detectRateController
+	tracker *faceTracker
+
+	// 前処理の段だけが使う状態
+	lastPreview time.Time
+	lighting    *lightingMonitor
+	preview     utils.JPEGEncoder // フレーム全体。切り抜かず品質も同じなら検出にもそのまま使う
+	detect      utils.JPEGEncoder // 切り抜き・補正したフレーム
+}
+
+// capturedFrame はキャプチャの段で受け取ったフレームと撮影時刻。
+type capturedFrame struct {
+	frame    camera.Frame
+	captured time.Time
+}
+
+// detectJob は前処理から判定まで 1 フレームに付いて回る。
+type detectJob struct {
+	in   *usecase.WatchSquatInput
+	jpeg *[]byte // in.Frame の持ち主。判定が終わったら jpegBufPool に返す
+	face *entity.Face
+	err  error
+}
+
+func (j *detectJob) release() {
+	*j.jpeg = (*j.jpeg)[:0]
+	jpegBufPool.Put(j.jpeg)
+}
+
+// startPipeline は前処理・検出・判定の段をそれぞれゴルーチンで起動する。終わったら stop を呼ぶこと。
+func (s *CameraService) startPipeline(ctx context.Context, live bool, recorder *session.Recorder) *detectPipeline {
+	p := &detectPipeline{
+		s:        s,
+		ctx:      ctx,
+		live:     live,
+		recorder: recorder,
+		frames:   make(chan capturedFrame, 1),
+		jobs:     make(chan *detectJob, 1),
+		results:  make(chan *detectJob, judgeQueueSize),
+		done:     make(chan struct{}),
+		lighting: newLightingMonitor(s.LightingRepeat),
+		rate:     newDetectRateController(s.DetectionRate, live),
+		tracker:  &faceTracker{conf: s.Tracking},
+	}
+	s.detectRate.Store(p.rate)
+	go p.runPreprocess()
+	go p.runDetect()
+	go p.runJudge()
+	return p
+}
+
+// push はキャプチャの段から前処理にフレームを渡す。f の解放はパイプラインが行う。
+// 判定の時刻にはソースが付けた撮影時刻を使い、エンコードや検出の待ち時間を含めない。
+func (p *detectPipeline) push(f camera.Frame) {
+	captured := f.Timestamp
+	if captured.IsZero() {
+		// 撮影時刻を持たないソースは受け取った時刻で代用する
+		captured = time.Now()
+	}
+	sendLatest(p.frames, capturedFrame{frame: f, captured: captured}, p.live, func(old capturedFrame) {
+		old.frame.Release()
+	})
+}
+
+// stop はフレームの受け付けを終え、後段が残りを処理し終えるのを待つ。
+func (p *detectPipeline) stop() {
+	close(p.frames)
+	<-p.done
+	p.s.detectRate.CompareAndSwap(p.rate, nil)
+}
+
+func (p *detectPipeline) runPreprocess() {
+	defer close(p.jobs)
+	for cf := range p.frames {
+		job := p.preprocess(cf)
+		cf.frame.Release()
+		if job != nil {
+			sendLatest(p.jobs, job, p.live, (*detectJob).release)
+		}
+	}
+}
+
+func (p *detectPipeline) runDetect() {
+	defer close(p.results)
+	for job := range p.jobs {
+		job.face, job.err = p.s.InputPort.Detect(p.ctx, job.in)
+		if job.err == nil {
+			p.tracker.update(job.face)
+		}
+		p.results <- job
+	}
+}
+
+func (p *detectPipeline) runJudge() {
+	defer close(p.done)
+	var last time.Time
+	for job := range p.results {
+		// 判定は状態を持つため、前に判定したフレームより古い結果は使わない
+		if !job.in.Timestamp.Before(last) {
+			last = job.in.Timestamp
+			var judgement *entity.Judgement
+			if out := p.judge(job); out != nil {
+				judgement = out.Judgement
+			}
+			p.rate.detected(judgement, time.Now())
+		}
+		job.release()
+	}
+}
+
+// preprocess は 1 フレームをプレビューに送り、ROI で切り抜いて検出用の JPEG にする。
+// JPEG へのエンコードはプレビューと検出でそれぞれ最大 1 回で、同じ画像なら検出はプレビューの結果を使う。
+// cf.frame は呼び出し側が解放する。ここで作った切り抜き・補正後のフレームはエンコード後に解放する。
+func (p *detectPipeline) preprocess(cf capturedFrame) *detectJob {
+	s, f := p.s, cf.frame
+	var fullJPEG []byte
+	// 見ている画面がなければプレビューのエンコードごと省く
+	if time.Since(p.lastPreview) >= s.previewPeriod() && s.previewWanted() {
+		// プレビューは ROI を描けるよう常にフレーム全体を送る
+		quality := s.previewQuality()
+		jpegBytes, err := p.preview.Encode(utils.Frame{Data: f.Data, Width: f.Width, Height: f.Height}, quality)
+		if err == nil && len(jpegBytes) > 0 {
+			p.lastPreview = time.Now()
+			if quality == jpegQuality {
+				fullJPEG = jpegBytes
+			}
+			s.OnPreview(jpegBytes)
+		}
+	}
+
+	in := &usecase.WatchSquatInput{Timestamp: cf.captured, FrameWidth: f.Width, FrameHeight: f.Height}
+	detectFrame := f
+	if roi := s.roi(); !roi.IsFull() {
+		rect := roi.Rect(f.Width, f.Height)
+		detectFrame = f.Crop(rect.X, rect.Y, rect.Width, rect.Height)
+		in.Crop = &rect
+	}
+	// 動きは顔を探す範囲で見る。動きがなく立っている間は検出を間引く（プレビューは間引かない）
+	if !p.rate.observe(detectFrame, cf.captured) {
+		if in.Crop != nil {
+			detectFrame.Release()
+		}
+		return nil
+	}
+	// 明るさは顔を探す範囲（ROI）で判定する
+	stats := camera.AnalyzeLighting(detectFrame)
+	condition := stats.Condition()
+	corrected := s.CorrectLighting && condition != camera.LightingOK
+
+	// 前回の顔が見つかっていれば、その周りだけを検出に回す。見つからなければ次のフレームは ROI 全体から探す
+	area := entity.Rect{Width: f.Width, Height: f.Height}
+	if in.Crop != nil {
+		area = *in.Crop
+	}
+	if rect, ok := p.tracker.window(area, cf.captured); ok {
+		if in.Crop != nil {
+			detectFrame.Release()
+		}
+		detectFrame = f.Crop(rect.X, rect.Y, rect.Width, rect.Height)
+		in.Crop = &rect
+	}
+	if corrected {
+		correctedFrame := camera.CorrectLighting(detectFrame, stats)
+		if in.Crop != nil {
+			detectFrame.Release()
+		}
+		detectFrame = correctedFrame
+	}
+	if s.OnLighting != nil && p.lighting.observe(condition, time.Now()) {
+		s.OnLighting(LightingWarning{
+			Condition:    string(condition),
+			Mean:         stats.Mean,
+			CenterMean:   stats.CenterMean,
+			SurroundMean: stats.SurroundMean,
+			Corrected:    corrected,
+		})
+	}
+
+	jpegBytes := fullJPEG
+	if in.Crop != nil || corrected || jpegBytes == nil {
+		var err error
+		jpegBytes, err = p.detect.Encode(utils.Frame{Data: detectFrame.Data, Width: detectFrame.Width, Height: detectFrame.Height}, jpegQuality)
+		if in.Crop != nil || corrected {
+			detectFrame.Release()
+		}
+		if err != nil || len(jpegBytes) == 0 {
+			return nil
+		}
+	}
+	buf := jpegBufPool.Get().(*[]byte)
+	*buf = append((*buf)[:0], jpegBytes...)
+	in.Frame = *buf
+	return &detectJob{in: in, jpeg: buf}
+}
+
+// judge は検出結果を判定し、記録してフロントに通知する。
+func (p *detectPipeline) judge(job *detectJob) *usecase.WatchSquatOutput {
+	var out *usecase.WatchSquatOutput
+	err := job.err
+	if err == nil {
+		out, err = p.s.InputPort.Judge(job.face)
+	}
+	if p.recorder != nil {
+		if rerr := p.recorder.RecordFrame(job.in, out); rerr != nil {
+			log.Println(rerr)
+		}
+	}
+	if err != nil {
+		return nil
+	}
+	if p.s.OnResult != nil && out != nil && out.Face != nil && out.Judgement != nil {
+		p.s.OnResult(out)
+	}
+	return out
+}
+
+// sendLatest は ch に v を送る。latestOnly なら ch が埋まっているときに古い値を取り出して drop に渡し、
+// 最新の v に置き換える（送り手が 1 つのチャネルにだけ使う）。latestOnly でなければ空くまで待つ。
+func sendLatest[T any](ch chan T, v T, latestOnly bool, drop func(T)) {
+	if !latestOnly {
+		ch <- v
+		return
+	}
+	for {
+		select {
+		case ch <- v:
+			return
+		default:
+		}
+		select {
+		case old := <-ch:
+			drop(old)
+		default:
+		}
+	}
+}
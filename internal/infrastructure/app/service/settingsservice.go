@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
 	"github.com/kikils/desk-squat-tracker/internal/usecase"
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
@@ -10,6 +11,16 @@ import (
 type SettingsService struct {
 	GetSettingInputPort    usecase.GetSettingInputPort
 	UpdateSettingInputPort usecase.UpdateSettingInputPort
+	// 取り込み設定の変更を、開いているフレームソースに反映する
+	UpdateCaptureSettingInputPort usecase.UpdateCaptureSettingInputPort
+	UpdateROIInputPort            usecase.UpdateROIInputPort
+	OnCaptureSettingChanged       func()
+	// ローカル HTTP API の設定。変更したら OnAPISettingChanged で待ち受けを開き直す
+	UpdateAPISettingInputPort  usecase.UpdateAPISettingInputPort
+	OnAPISettingChanged        func(entity.APISetting) error
+	UpdateDailyGoalInputPort   usecase.UpdateDailyGoalInputPort
+	UpdateMQTTSettingInputPort usecase.UpdateMQTTSettingInputPort
+	OnMQTTSettingChanged       func(entity.MQTTSetting) error
 
 	ctx context.Context
 }
@@ -26,3 +37,66 @@ func (s *SettingsService) GetSetting() (*usecase.GetSettingOutput, error) {
 func (s *SettingsService) UpdateSetting(topRatio, bottomRatio float64) error {
 	return s.UpdateSettingInputPort.Execute(s.ctx, topRatio, bottomRatio)
 }
+
+// UpdateCaptureSetting は取り込みの解像度・フレームレート・回転・左右反転を保存し、キャプチャ中のストリームにも反映する。
+func (s *SettingsService) UpdateCaptureSetting(in *usecase.UpdateCaptureSettingInput) error {
+	if err := s.UpdateCaptureSettingInputPort.Execute(s.ctx, in); err != nil {
+		return err
+	}
+	if s.OnCaptureSettingChanged != nil {
+		s.OnCaptureSettingChanged()
+	}
+	return nil
+}
+
+// UpdateROI は顔検出の対象にする範囲（フレームに対する比率）を保存する。width か height が 0 ならフレーム全体に戻す。
+func (s *SettingsService) UpdateROI(x, y, width, height float64) error {
+	if err := s.UpdateROIInputPort.Execute(s.ctx, entity.Region{X: x, Y: y, Width: width, Height: height}); err != nil {
+		return err
+	}
+	if s.OnCaptureSettingChanged != nil {
+		s.OnCaptureSettingChanged()
+	}
+	return nil
+}
+
+// UpdateMQTTSetting は MQTT の設定を保存して反映する。ブローカーにつながるまで待たずに返す。
+// Password が nil なら保存済みのパスワードを使う。返す設定にパスワードは含めない。
+func (s *SettingsService) UpdateMQTTSetting(in *usecase.UpdateMQTTSettingInput) (*usecase.MQTTSettingOutput, error) {
+	saved, err := s.UpdateMQTTSettingInputPort.Execute(s.ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	out := usecase.MQTTSettingOutputFrom(*saved)
+	if s.OnMQTTSettingChanged != nil {
+		if err := s.OnMQTTSettingChanged(*saved); err != nil {
+			return &out, err
+		}
+	}
+	return &out, nil
+}
+
+// UpdateDailyGoal は 1 日の目標回数を保存する。0 なら目標なし。
+func (s *SettingsService) UpdateDailyGoal(goal int) error {
+	return s.UpdateDailyGoalInputPort.Execute(s.ctx, goal)
+}
+
+// UpdateAPISetting はローカル HTTP API の有効・無効とポートを保存して反映し、保存後の設定（トークンを含む）を返す。
+// regenerateToken なら新しいトークンを発行し、以前のトークンを使えなくする。
+func (s *SettingsService) UpdateAPISetting(enabled bool, port int, regenerateToken bool) (*entity.APISetting, error) {
+	setting, err := s.UpdateAPISettingInputPort.Execute(s.ctx, &usecase.UpdateAPISettingInput{
+		Enabled:         enabled,
+		Port:            port,
+		RegenerateToken: regenerateToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.OnAPISettingChanged != nil {
+		// 保存はできているので、待ち受けられなかった（ポートが使用中など）ことだけを返す
+		if err := s.OnAPISettingChanged(*setting); err != nil {
+			return setting, err
+		}
+	}
+	return setting, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+)
+
+const (
+	trackingMaxAge  = 2 * time.Second // これより古い顔の周りは探さず、全体から探し直す
+	trackingMinSize = 64              // 探索範囲の最小の幅・高さ（小さすぎると検出器が文脈を失う）
+	trackingMaxArea = 0.6             // 探索範囲が検出範囲のこの割合を超えるなら切り抜かない
+)
+
+// Tracking は前回見つけた顔の周りだけを検出に回す設定。
+// Width / Height は顔の大きさに対する探索範囲の倍率で、どちらかが 0 なら追跡しない。
+// スクワットでは顔が大きく上下するため、Height は Width より大きめにする。
+type Tracking struct {
+	Width  float64
+	Height float64
+}
+
+// faceTracker は直前に検出した顔を覚え、次のフレームの探索範囲を決める。
+// 検出の段が更新し、前処理の段が読む。
+type faceTracker struct {
+	conf Tracking
+	last atomic.Pointer[entity.Face]
+}
+
+// window は area（フレーム全体または ROI）の中で、前回の顔の周りの探索範囲を返す。
+// 追跡しない・顔を見失った・範囲が area とほとんど変わらない場合は false を返し、area 全体から探す。
+func (t *faceTracker) window(area entity.Rect, now time.Time) (entity.Rect, bool) {
+	if t.conf.Width <= 0 || t.conf.Height <= 0 {
+		return entity.Rect{}, false
+	}
+	face := t.last.Load()
+	if face == nil || now.Sub(face.Timestamp) > trackingMaxAge {
+		return entity.Rect{}, false
+	}
+	r := face.Rect().Expand(t.conf.Width, t.conf.Height)
+	if r.Width < trackingMinSize {
+		r = entity.Rect{X: r.X + r.Width/2 - trackingMinSize/2, Y: r.Y, Width: trackingMinSize, Height: r.Height}
+	}
+	if r.Height < trackingMinSize {
+		r = entity.Rect{X: r.X, Y: r.Y + r.Height/2 - trackingMinSize/2, Width: r.Width, Height: trackingMinSize}
+	}
+	r = r.Intersect(area)
+	if r.Empty() || float64(r.Width*r.Height) > trackingMaxArea*float64(area.Width*area.Height) {
+		return entity.Rect{}, false
+	}
+	return r, true
+}
+
+// update は検出結果で追跡を更新する。顔が見つからなければ、次のフレームは全体から探す。
+func (t *faceTracker) update(face *entity.Face) {
+	t.last.Store(face)
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// WebhookService は rep・セット・目標の達成を知らせる Webhook の設定と送信テスト。
+type WebhookService struct {
+	GetWebhooksInputPort     usecase.GetWebhooksInputPort
+	SaveWebhookInputPort     usecase.SaveWebhookInputPort
+	DeleteWebhookInputPort   usecase.DeleteWebhookInputPort
+	SendTestWebhookInputPort usecase.SendTestWebhookInputPort
+
+	ctx context.Context
+}
+
+func (s *WebhookService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	s.ctx = ctx
+	return nil
+}
+
+func (s *WebhookService) ListWebhooks() ([]entity.Webhook, error) {
+	return s.GetWebhooksInputPort.Execute(s.ctx)
+}
+
+// SaveWebhook は ID が空なら Webhook を追加し、あればその Webhook を置き換える。URL とテンプレートはここで検証する。
+func (s *WebhookService) SaveWebhook(webhook *entity.Webhook) (*entity.Webhook, error) {
+	return s.SaveWebhookInputPort.Execute(s.ctx, webhook)
+}
+
+func (s *WebhookService) DeleteWebhook(id string) error {
+	return s.DeleteWebhookInputPort.Execute(s.ctx, id)
+}
+
+// SendTestWebhook は test の出来事をすぐに送り、送れなければそのエラーを返す。
+func (s *WebhookService) SendTestWebhook(id string) error {
+	return s.SendTestWebhookInputPort.Execute(s.ctx, id)
+}
@@ -1,18 +1,12 @@
 package app
 
 import (
+	"time"
+
 	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
 	"github.com/kikils/desk-squat-tracker/internal/usecase"
 )
 
-var detectStateLabels = map[entity.DetectState]string{
-	entity.DetectStateUnknown:   "unknown",
-	entity.DetectStateStanding:  "standing",
-	entity.DetectStateGoingDown: "going_down",
-	entity.DetectStateBottom:    "bottom",
-	entity.DetectStateGoingUp:   "going_up",
-}
-
 // FaceViewModel はフロント用の顔検出表示モデル。app 層で定義する。
 type FaceViewModel struct {
 	X            int     `json:"x"`
@@ -45,7 +39,25 @@ func FaceViewModelFrom(out *usecase.WatchSquatOutput) *FaceViewModel {
 		FrameWidth:   face.FrameWidth,
 		FrameHeight:  face.FrameHeight,
 		Ratio:        ratio,
-		State:        detectStateLabels[judgement.State],
+		State:        judgement.State.String(),
 		RepCompleted: judgement.IsRepCompleted,
 	}
 }
+
+// WorkoutEventViewModel は SSE で送るセットの終わり・目標の達成。
+type WorkoutEventViewModel struct {
+	Timestamp  time.Time `json:"timestamp"`
+	TodayCount int       `json:"todayCount"`
+	SetReps    int       `json:"setReps,omitempty"`
+	Goal       int       `json:"goal,omitempty"`
+}
+
+// WorkoutEventViewModelFrom は WorkoutEvent を ViewModel に変換する。
+func WorkoutEventViewModelFrom(ev entity.WorkoutEvent) WorkoutEventViewModel {
+	return WorkoutEventViewModel{
+		Timestamp:  ev.Timestamp,
+		TodayCount: ev.TodayCount,
+		SetReps:    ev.SetReps,
+		Goal:       ev.Goal,
+	}
+}
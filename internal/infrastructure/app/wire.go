@@ -0,0 +1,367 @@
+package app
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/config"
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	dservice "github.com/kikils/desk-squat-tracker/internal/domain/service"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/api"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/app/service"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/camera"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/file"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/memory"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/mqtt"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/preview"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/python"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/session"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/webhook"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+func init() {
+	application.RegisterEvent[string]("time")
+	application.RegisterEvent[int]("squat")
+	application.RegisterEvent[*FaceViewModel]("face")
+	application.RegisterEvent[service.CameraStatus]("cameraStatus")
+	application.RegisterEvent[service.LightingWarning]("lightingWarning")
+}
+
+// services は GUI とサーバー（-tags server）のどちらの起動でも共有する、ユースケースを組み立てたサービス群。
+// 通知先（OnResult など）は起動側で設定する。
+type services struct {
+	app      *service.AppService
+	camera   *service.CameraService
+	stats    *service.StatsService
+	settings *service.SettingsService
+	webhooks *service.WebhookService
+	preview  *preview.Hub
+	api      *api.Server
+	mqtt     *mqtt.Publisher
+	notify   usecase.NotifyWorkoutInputPort
+	delivery *webhook.Dispatcher
+	// settingRepository は起動時に連携の設定を読む（MQTT のパスワードは GetSetting が返さないため直接読む）
+	settingRepository repository.SettingRepository
+	demo              bool
+}
+
+// newServices は設定に従ってリポジトリ・フレームソースを用意し、各サービスを組み立てる。
+func newServices() (*services, error) {
+	// デモモードではカメラと顔検出ヘルパーの代わりにシミュレーターを使う
+	demoConf := config.Get().Demo
+	var faceRepository repository.FaceRepository
+	var frameSource camera.FrameSource
+	// transformSource は設定の解像度・回転などをすべてのソースに適用する（デモでは顔の位置がずれるため使わない）
+	var transformSource *camera.TransformSource
+	if demoConf.Enabled {
+		faceRepository, frameSource = newDemo(demoConf)
+	} else {
+		var err error
+		if faceRepository, err = python.NewMediaPipeFaceRepository(); err != nil {
+			return nil, err
+		}
+		cameraConf := config.Get().Camera
+		transformSource = camera.NewTransformSource(camera.NewMultiSource(
+			camera.NewAVFoundationSource(),
+			camera.NewMJPEGSource(cameraConf.NetworkURLs...),
+			camera.NewFileSource(cameraConf.FileFPS, cameraConf.FileSpeed, cameraConf.FilePaths...),
+		))
+		frameSource = transformSource
+	}
+
+	judgementRepository := memory.NewJudgementRepository()
+	settingRepository, err := file.NewSettingRepository()
+	if err != nil {
+		return nil, err
+	}
+	squatJudger := dservice.NewSquatJudger(faceRepository, judgementRepository, settingRepository)
+	cameraSvc := &service.CameraService{
+		Source:    frameSource,
+		InputPort: usecase.NewWatchSquatUsecase(faceRepository, judgementRepository, squatJudger),
+
+		GetSettingInputPort:   usecase.NewGetSettingUsecase(settingRepository),
+		SelectCameraInputPort: usecase.NewSelectCameraUsecase(settingRepository),
+
+		CorrectLighting: config.Get().Lighting.Correction,
+		LightingRepeat:  config.Get().Lighting.RepeatInterval,
+		DetectionRate: service.DetectionRate{
+			IdleFPS:       config.Get().Detection.IdleFPS,
+			ActiveFPS:     config.Get().Detection.ActiveFPS,
+			VerticalSpeed: config.Get().Detection.VerticalSpeed,
+			ChangeRatio:   config.Get().Detection.ChangeRatio,
+			Hold:          config.Get().Detection.Hold,
+		},
+	}
+	if !demoConf.Enabled {
+		// シミュレーターは切り抜きを見ずにフレーム全体の座標を返すため、デモでは追跡しない
+		cameraSvc.Tracking = service.Tracking{
+			Width:  config.Get().Detection.TrackingWidth,
+			Height: config.Get().Detection.TrackingHeight,
+		}
+		// ブラウザから送られるフレームにも、ネイティブのソースと同じ取り込み設定を適用する
+		cameraSvc.PushTransform = transformSource.Push
+	}
+	statsSvc := &service.StatsService{
+		InputPort: usecase.NewGetStatsUsecase(judgementRepository),
+	}
+	apiServer := &api.Server{
+		GetStatsInputPort:      usecase.NewGetStatsUsecase(judgementRepository),
+		GetStatsRangeInputPort: usecase.NewGetStatsRangeUsecase(judgementRepository),
+		GetSettingInputPort:    usecase.NewGetSettingUsecase(settingRepository),
+		UpdateSettingInputPort: usecase.NewUpdateSettingUsecase(settingRepository),
+		Capture:                cameraSvc,
+	}
+	mqttPublisher := &mqtt.Publisher{
+		GetStatsInputPort: usecase.NewGetStatsUsecase(judgementRepository),
+		PresenceTimeout:   config.Get().MQTT.PresenceTimeout,
+	}
+	// 取り込み設定はフレームごとにファイルを読まないよう、変更時にだけ読み直して反映する
+	var roi atomic.Pointer[entity.Region]
+	roi.Store(&entity.Region{})
+	applyCaptureSetting := func() {
+		if transformSource == nil {
+			return
+		}
+		setting, err := settingRepository.Get()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		roi.Store(&setting.ROI)
+		transformSource.SetTransform(camera.Transform{
+			Width:    setting.CaptureWidth,
+			Height:   setting.CaptureHeight,
+			MaxFPS:   setting.MaxFPS,
+			Rotation: setting.Rotation,
+			Mirror:   setting.Mirror,
+		})
+	}
+	applyCaptureSetting()
+	settingsSvc := &service.SettingsService{
+		GetSettingInputPort:           usecase.NewGetSettingUsecase(settingRepository),
+		UpdateSettingInputPort:        usecase.NewUpdateSettingUsecase(settingRepository),
+		UpdateCaptureSettingInputPort: usecase.NewUpdateCaptureSettingUsecase(settingRepository),
+		UpdateROIInputPort:            usecase.NewUpdateROIUsecase(settingRepository),
+		OnCaptureSettingChanged:       applyCaptureSetting,
+		UpdateAPISettingInputPort:     usecase.NewUpdateAPISettingUsecase(settingRepository),
+		OnAPISettingChanged:           apiServer.Apply,
+		UpdateDailyGoalInputPort:      usecase.NewUpdateDailyGoalUsecase(settingRepository),
+		UpdateMQTTSettingInputPort:    usecase.NewUpdateMQTTSettingUsecase(settingRepository),
+		OnMQTTSettingChanged:          mqttPublisher.Apply,
+	}
+	queuePath, err := file.WebhookQueuePath()
+	if err != nil {
+		return nil, err
+	}
+	dispatcher, err := webhook.NewDispatcher(queuePath)
+	if err != nil {
+		return nil, err
+	}
+	webhookSvc := &service.WebhookService{
+		GetWebhooksInputPort:     usecase.NewGetWebhooksUsecase(settingRepository),
+		SaveWebhookInputPort:     usecase.NewSaveWebhookUsecase(settingRepository, dispatcher),
+		DeleteWebhookInputPort:   usecase.NewDeleteWebhookUsecase(settingRepository, dispatcher),
+		SendTestWebhookInputPort: usecase.NewSendTestWebhookUsecase(settingRepository, judgementRepository, dispatcher),
+	}
+	workoutTracker := dservice.NewWorkoutTracker(judgementRepository, settingRepository, config.Get().Workout.SetRest)
+	cameraSvc.ROI = func() entity.Region { return *roi.Load() }
+	// プレビューはイベントではなくアセットサーバーのエンドポイントで、見ている画面があるときだけ配信する
+	previewHub, err := preview.NewHub()
+	if err != nil {
+		return nil, err
+	}
+	cameraSvc.OnPreview = previewHub.Publish
+	cameraSvc.PreviewWanted = previewHub.Wanted
+	cameraSvc.PreviewPath = previewHub.URL()
+	cameraSvc.PreviewFPS = config.Get().Preview.FPS
+	cameraSvc.PreviewQuality = config.Get().Preview.Quality
+	if rc := config.Get().Recorder; rc.Enabled {
+		cameraSvc.NewRecorder = func() (*session.Recorder, error) {
+			setting, err := settingRepository.Get()
+			if err != nil {
+				return nil, err
+			}
+			dir := rc.Dir
+			if dir == "" {
+				if dir, err = file.SessionDir(); err != nil {
+					return nil, err
+				}
+			}
+			return session.NewRecorder(dir, rc.Privacy, setting)
+		}
+	}
+
+	s := &services{
+		app:      &service.AppService{},
+		camera:   cameraSvc,
+		stats:    statsSvc,
+		settings: settingsSvc,
+		webhooks: webhookSvc,
+		preview:  previewHub,
+		api:      apiServer,
+		mqtt:     mqttPublisher,
+		delivery: dispatcher,
+
+		settingRepository: settingRepository,
+		demo:              demoConf.Enabled,
+	}
+	// 休憩でセットが終わったときは判定を待たずに通知されるので、SSE にもそこから送る
+	s.notify = usecase.NewNotifyWorkoutUsecase(workoutTracker, settingRepository, dispatcher, s.publishWorkout)
+	return s, nil
+}
+
+// squatEvent は SSE の squat イベント（rep を 1 回数えた）。
+type squatEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// stateEvent は SSE の state イベント（しゃがみの判定状態が変わった）。
+type stateEvent struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// bind はフロントに公開するサービスを返す。
+func (s *services) bind() []application.Service {
+	return []application.Service{
+		application.NewService(&service.GreetService{}),
+		application.NewService(s.app),
+		application.NewService(s.camera),
+		application.NewService(s.stats),
+		application.NewService(s.settings),
+		application.NewService(s.webhooks),
+		// ほかのサービスが起動してから API を待ち受ける（キャプチャの操作に CameraService の起動が要るため）
+		application.NewService(&integrations{server: s.api, mqtt: s.mqtt, settings: s.settingRepository, delivery: s.delivery}),
+	}
+}
+
+// integrations は外部との連携（ローカル HTTP API・Webhook の送信・MQTT）を、アプリの起動時に始め、終了時に止める。
+// MQTT は offline を送ってから切断するよう、起動側で app.OnShutdown から閉じる。
+type integrations struct {
+	server   *api.Server
+	mqtt     *mqtt.Publisher
+	settings repository.SettingRepository
+	delivery *webhook.Dispatcher
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (l *integrations) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	// 前回送れずに残ったものも含め、キューの送信はアプリが動いている間ずっと続ける
+	var runCtx context.Context
+	runCtx, l.cancel = context.WithCancel(context.Background())
+	l.done = make(chan struct{})
+	go func() {
+		defer close(l.done)
+		l.delivery.Run(runCtx)
+	}()
+
+	setting, err := l.settings.Get()
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	// ポートが使用中などで待ち受けられなくても、アプリ自体は起動する
+	if err := l.server.Apply(setting.API); err != nil {
+		log.Println(err)
+	}
+	if err := l.mqtt.Apply(setting.MQTT); err != nil {
+		log.Println(err)
+	}
+	return nil
+}
+
+func (l *integrations) ServiceShutdown() error {
+	l.server.Close()
+	if l.cancel != nil {
+		// 送信中のものは中断し、次回の起動時にキューから送り直す
+		l.cancel()
+		<-l.done
+	}
+	return nil
+}
+
+// emitEvents はキャプチャの結果・状態を、フロント向けのイベントとローカル HTTP API の SSE に送るよう配線する。
+// onJudgement が非 nil なら、判定のたびに呼ぶ（トレイアイコンの切り替えなど）。
+func (s *services) emitEvents(app *application.App, onJudgement func(*entity.Judgement)) {
+	// 判定の段は 1 つのゴルーチンで動くが、キャプチャを切り替えた直後は前後の段が重なることがある
+	var lastState atomic.Int32
+	s.camera.OnResult = func(out *usecase.WatchSquatOutput) {
+		if vm := FaceViewModelFrom(out); vm != nil {
+			app.Event.Emit("face", vm)
+			s.api.Publish("face", vm)
+		}
+		if out != nil && out.Judgement != nil {
+			s.mqtt.FaceSeen(out.Judgement.Timestamp, out.Judgement.State)
+			if out.Judgement.IsRepCompleted {
+				s.mqtt.RepCompleted(out.Judgement.Timestamp)
+				app.Event.Emit("squat", 0)
+				s.api.Publish("squat", squatEvent{Timestamp: out.Judgement.Timestamp})
+			}
+			if prev := entity.DetectState(lastState.Swap(int32(out.Judgement.State))); prev != out.Judgement.State {
+				s.api.Publish("state", stateEvent{From: prev.String(), To: out.Judgement.State.String(), Timestamp: out.Judgement.Timestamp})
+			}
+			events, err := s.notify.Execute(context.Background(), out.Judgement)
+			if err != nil {
+				log.Println(err)
+			}
+			s.publishWorkout(events)
+			if onJudgement != nil {
+				onJudgement(out.Judgement)
+			}
+		}
+	}
+	s.camera.OnStatus = func(status service.CameraStatus) {
+		app.Event.Emit("cameraStatus", status)
+		s.api.Publish("cameraStatus", status)
+		if status.State == service.CameraStateStopped || status.State == service.CameraStateEnded {
+			s.mqtt.CaptureStopped()
+			// キャプチャを止めたら、休憩を待たずにいまのセットを終わらせる
+			events, err := s.notify.Flush(context.Background())
+			if err != nil {
+				log.Println(err)
+			}
+			s.publishWorkout(events)
+		}
+	}
+	s.camera.OnLighting = func(warning service.LightingWarning) {
+		app.Event.Emit("lightingWarning", warning)
+	}
+}
+
+// publishWorkout はセットの終わり・目標の達成を SSE に送る（rep は squat イベントで送っている）。
+func (s *services) publishWorkout(events []entity.WorkoutEvent) {
+	for _, ev := range events {
+		if ev.Type == entity.WorkoutEventSet || ev.Type == entity.WorkoutEventGoal {
+			s.api.Publish(string(ev.Type), WorkoutEventViewModelFrom(ev))
+		}
+	}
+}
+
+// startHelper は顔検出ヘルパーを起動する（デモモードでは起動しない）。
+// 改ざんの疑いがあるヘルパーは起動せず、アプリは起動したままエラーをフロントに表示する。
+func (s *services) startHelper(ctx context.Context) error {
+	if s.demo {
+		log.Println("demo mode: using the simulated user instead of the camera and face_detect helper")
+		return nil
+	}
+	err := python.StartFaceDetectServer(ctx)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, errors.ErrIntegrity) {
+		return err
+	}
+	log.Println(err)
+	s.app.HelperError = err
+	s.camera.DetectorError = err
+	return nil
+}
@@ -0,0 +1,45 @@
+package camera
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// AVFoundationSource は macOS の AVFoundation でカメラから取り込む FrameSource。
+// macOS 以外では一覧にデフォルトカメラのみを返し、Open はエラーになる。
+type AVFoundationSource struct{}
+
+func NewAVFoundationSource() FrameSource {
+	return &AVFoundationSource{}
+}
+
+func (s *AVFoundationSource) ListDevices() ([]Device, error) {
+	devs, err := listDevices()
+	for i := range devs {
+		devs[i].Live = true
+	}
+	return devs, err
+}
+
+// Open は指定デバイスでキャプチャを開始する。macOS のみ対応。
+func (s *AVFoundationSource) Open(ctx context.Context, deviceIndex int) (Stream, error) {
+	if runtime.GOOS != "darwin" {
+		return nil, fmt.Errorf("camera: unsupported platform %s", runtime.GOOS)
+	}
+	devs, _ := listDevices()
+	if deviceIndex < 0 || deviceIndex >= len(devs) {
+		return nil, fmt.Errorf("camera: device index %d out of range (0..%d)", deviceIndex, len(devs)-1)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	frames, err := startStreamDarwin(ctx, deviceIndex)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return NewChanStream(frames, cancel), nil
+}
+
+func (s *AVFoundationSource) Capabilities() Capabilities {
+	return Capabilities{Supported: runtime.GOOS == "darwin", Live: true}
+}
@@ -10,9 +10,11 @@ package camera
 #import <CoreMedia/CoreMedia.h>
 #import <CoreVideo/CoreVideo.h>
 #import <Foundation/Foundation.h>
+#include <string.h>
 
-#define CIF_WIDTH  352
-#define CIF_HEIGHT 288
+// 取り込みの上限。最終的な解像度・回転は Go 側の Transform で決める。
+#define MAX_CAPTURE_WIDTH  640
+#define MAX_CAPTURE_HEIGHT 480
 
 // キャプチャ用グローバル。デリゲートは gQueue（直列）で動作し、gLock で gFrameBuf/gFrameReady を保護。
 // Go からは C を呼ぶのみ（C→Go コールバックなし）なので CGO のスレッド制約に注意不要。
@@ -22,6 +24,7 @@ static uint8_t *gFrameBuf;
 static int gFrameWidth;
 static int gFrameHeight;
 static int gFrameReady;
+static double gFramePTS; // 最新フレームの提示時刻（ホストクロックの秒）。不明なら 0
 static NSLock *gLock;
 
 static inline uint8_t clampByte(int v) {
@@ -49,27 +52,21 @@ didOutputSampleBuffer:(CMSampleBufferRef)sampleBuffer
 		CVPixelBufferUnlockBaseAddress(img, kCVPixelBufferLock_ReadOnly);
 		return;
 	}
+	// 上限を超える場合は縦横比を保ったまま縮小する（切り抜きはしない）
 	size_t dstW = w, dstH = h, srcX0 = 0, srcY0 = 0, srcW = w, srcH = h;
-	if (w > CIF_WIDTH || h > CIF_HEIGHT) {
-		double dstAspect = (double)CIF_WIDTH / (double)CIF_HEIGHT;
-		double srcAspect = (double)w / (double)h;
-		dstW = CIF_WIDTH;
-		dstH = CIF_HEIGHT;
-		if (srcAspect > dstAspect) {
-			srcH = h;
-			srcW = (size_t)((double)h * dstAspect);
-			if (srcW > w) srcW = w;
-			srcX0 = (w > srcW) ? (w - srcW) / 2 : 0;
-			srcY0 = 0;
-		} else {
-			srcW = w;
-			srcH = (size_t)((double)w / dstAspect);
-			if (srcH > h) srcH = h;
-			srcX0 = 0;
-			srcY0 = (h > srcH) ? (h - srcH) / 2 : 0;
-		}
+	if (w > MAX_CAPTURE_WIDTH || h > MAX_CAPTURE_HEIGHT) {
+		double scaleW = (double)MAX_CAPTURE_WIDTH / (double)w;
+		double scaleH = (double)MAX_CAPTURE_HEIGHT / (double)h;
+		double scale = scaleW < scaleH ? scaleW : scaleH;
+		dstW = (size_t)((double)w * scale);
+		dstH = (size_t)((double)h * scale);
+		if (dstW < 1) dstW = 1;
+		if (dstH < 1) dstH = 1;
 	}
 	size_t bufSize444 = dstW * dstH * 3;
+	// キャプチャデバイスの提示時刻はホストクロック基準なので、Go 側で現在時刻との差から撮影時刻を求める
+	CMTime ptsTime = CMSampleBufferGetPresentationTimeStamp(sampleBuffer);
+	double pts = CMTIME_IS_NUMERIC(ptsTime) ? CMTimeGetSeconds(ptsTime) : 0;
 
 	if (fmt == kCVPixelFormatType_420YpCbCr8BiPlanarFullRange || fmt == kCVPixelFormatType_420YpCbCr8BiPlanarVideoRange) {
 		if (!CVPixelBufferIsPlanar(img) || CVPixelBufferGetPlaneCount(img) < 2) {
@@ -114,6 +111,7 @@ didOutputSampleBuffer:(CMSampleBufferRef)sampleBuffer
 				}
 			}
 			gFrameReady = 1;
+			gFramePTS = pts;
 		}
 		[gLock unlock];
 		CVPixelBufferUnlockBaseAddress(img, kCVPixelBufferLock_ReadOnly);
@@ -157,6 +155,7 @@ didOutputSampleBuffer:(CMSampleBufferRef)sampleBuffer
 				}
 			}
 			gFrameReady = 1;
+			gFramePTS = pts;
 		}
 		[gLock unlock];
 	}
@@ -192,6 +191,22 @@ int ListCameraNameAtIndex(int index, char *buf, int bufLen) {
 		return (int)[data length];
 	}
 }
+// ListCameraUniqueIDAtIndex は AVCaptureDevice.uniqueID（接続し直しても変わらない ID）を buf に書き込む。
+int ListCameraUniqueIDAtIndex(int index, char *buf, int bufLen) {
+	if (!buf || bufLen <= 0) return -1;
+	@autoreleasepool {
+		NSArray *devices = getVideoDevices();
+		if (index < 0 || index >= (int)[devices count]) return -1;
+		AVCaptureDevice *dev = [devices objectAtIndex:(NSUInteger)index];
+		NSString *uid = dev.uniqueID;
+		if (!uid) return -1;
+		NSData *data = [uid dataUsingEncoding:NSUTF8StringEncoding];
+		if (!data || [data length] >= (NSUInteger)bufLen) return -1;
+		memcpy(buf, [data bytes], [data length]);
+		buf[[data length]] = '\0';
+		return (int)[data length];
+	}
+}
 
 // StartCaptureWithDeviceIndex: 0 ok, <0 error. device_index でカメラを指定。
 int StartCaptureWithDeviceIndex(int device_index) {
@@ -210,11 +225,13 @@ int StartCaptureWithDeviceIndex(int device_index) {
 		if (!session) return -3;
 
 		[session beginConfiguration];
-		if ([session canSetSessionPreset:AVCaptureSessionPreset352x288]) {
+		if ([session canSetSessionPreset:AVCaptureSessionPreset640x480]) {
+			session.sessionPreset = AVCaptureSessionPreset640x480;
+		} else if ([session canSetSessionPreset:AVCaptureSessionPreset352x288]) {
 			session.sessionPreset = AVCaptureSessionPreset352x288;
 		}
-		gFrameWidth = 352;
-		gFrameHeight = 288;
+		gFrameWidth = 0;
+		gFrameHeight = 0;
 
 		if (![session canAddInput:input]) return -4;
 		[session addInput:input];
@@ -266,19 +283,40 @@ void StopCaptureDarwin(void) {
 	}
 }
 
-// GetFrameDarwin は最新フレームを取得。*buf は C 側の gFrameBuf を指すので、Go 側で GoBytes でコピーすること。
-int GetFrameDarwin(uint8_t **buf, int *w, int *h, int *frameSizeOut) {
+// PeekFrameDarwin は新しいフレームがあればそのサイズを返す。フレームは消費しない。
+int PeekFrameDarwin(int *w, int *h) {
+	if (!gFrameBuf || !gLock) return -1;
+	[gLock lock];
+	int ready = gFrameReady;
+	*w = gFrameWidth;
+	*h = gFrameHeight;
+	[gLock unlock];
+	return ready ? 0 : -1;
+}
+
+// CopyFrameDarwin は最新フレームを gLock を保持したまま dst にコピーする。
+// dst は Go のプールから取り出したバッファで、容量が足りない（解像度が変わった）場合は -2 を返す。
+// *age には撮影（提示時刻）から今までの秒数を入れる。提示時刻が不明なら -1。
+int CopyFrameDarwin(uint8_t *dst, int capacity, int *w, int *h, double *age) {
 	if (!gFrameBuf || !gLock) return -1;
 	[gLock lock];
 	if (!gFrameReady) {
 		[gLock unlock];
 		return -1;
 	}
-	*buf = gFrameBuf;
+	int size = gFrameWidth * gFrameHeight * 3;
 	*w = gFrameWidth;
 	*h = gFrameHeight;
-	if (frameSizeOut) *frameSizeOut = gFrameWidth * gFrameHeight * 3;
+	if (size > capacity) {
+		[gLock unlock];
+		return -2;
+	}
+	memcpy(dst, gFrameBuf, size);
 	gFrameReady = 0;
+	*age = -1;
+	if (gFramePTS > 0) {
+		*age = CMTimeGetSeconds(CMClockGetTime(CMClockGetHostTimeClock())) - gFramePTS;
+	}
 	[gLock unlock];
 	return 0;
 }
@@ -315,28 +353,50 @@ func runDarwinCaptureLoop(ctx context.Context, out chan<- Frame) {
 			return
 		default:
 		}
-		var cbuf *C.uchar
-		var cw, ch, csize C.int
-		if C.GetFrameDarwin(&cbuf, &cw, &ch, &csize) != 0 {
+		var cw, ch C.int
+		if C.PeekFrameDarwin(&cw, &ch) != 0 {
 			time.Sleep(10 * time.Millisecond)
 			continue
 		}
-		w, h, size := int(cw), int(ch), int(csize)
-		if w <= 0 || h <= 0 || size <= 0 || cbuf == nil || size != w*h*3 {
+		w, h := int(cw), int(ch)
+		if w <= 0 || h <= 0 {
 			time.Sleep(5 * time.Millisecond)
 			continue
 		}
-		data := C.GoBytes(unsafe.Pointer(cbuf), C.int(size))
+		// C のバッファは次のコールバックで上書きされるため、ロックを持ったままプールのバッファへコピーする
+		data := NewFrameBuffer(w * h * 3)
+		var age C.double
+		if C.CopyFrameDarwin((*C.uint8_t)(unsafe.Pointer(&data[0])), C.int(len(data)), &cw, &ch, &age) != 0 {
+			// Peek の後に解像度が変わった場合などは次のフレームを待つ
+			Frame{Data: data}.Release()
+			continue
+		}
+		if int(cw) != w || int(ch) != h {
+			data = data[:int(cw)*int(ch)*3]
+			w, h = int(cw), int(ch)
+		}
 		select {
-		case out <- Frame{Data: data, Width: w, Height: h}:
+		case out <- Frame{Data: data, Width: w, Height: h, Timestamp: darwinCaptureTime(float64(age))}:
 		case <-ctx.Done():
+			Frame{Data: data}.Release()
 			return
 		default:
 			// ドロップ
+			Frame{Data: data}.Release()
 		}
 	}
 }
 
+// darwinCaptureTime は撮影からの経過秒数 age を現在時刻から引いて撮影時刻にする。
+// time.Now から求めるので単調増加の読みも保たれる。age が不明・異常なら取り出した時刻を使う。
+func darwinCaptureTime(age float64) time.Time {
+	now := time.Now()
+	if age < 0 || age > 1 {
+		return now
+	}
+	return now.Add(-time.Duration(age * float64(time.Second)))
+}
+
 func listDevicesDarwin() ([]Device, error) {
 	n := int(C.ListCameraCount())
 	if n <= 0 {
@@ -348,11 +408,24 @@ func listDevicesDarwin() ([]Device, error) {
 		if name == "" {
 			name = "Camera " + strconv.Itoa(i)
 		}
-		devs = append(devs, Device{Index: i, Name: name, ID: ""})
+		id := darwinDeviceIDAt(i)
+		if id != "" {
+			id = "avfoundation:" + id
+		}
+		devs = append(devs, Device{Index: i, Name: name, ID: id})
 	}
 	return devs, nil
 }
 
+func darwinDeviceIDAt(index int) string {
+	buf := make([]byte, 256)
+	written := int(C.ListCameraUniqueIDAtIndex(C.int(index), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf))))
+	if written <= 0 || written >= len(buf) {
+		return ""
+	}
+	return string(buf[:written])
+}
+
 func darwinDeviceNameAt(index int) string {
 	buf := make([]byte, 256)
 	written := int(C.ListCameraNameAtIndex(C.int(index), (*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf))))
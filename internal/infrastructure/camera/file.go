@@ -0,0 +1,181 @@
+package camera
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// FileSource は静止画の連番ディレクトリ（JPEG/PNG）や MJPEG / Y4M ファイルを再生する FrameSource。
+// フレームは取りこぼさずに順番どおり渡し、終端でストリームを閉じる。
+type FileSource struct {
+	paths []string
+	fps   float64 // Y4M 以外（フレームレート情報を持たない形式）のフレームレート
+	speed float64 // 再生速度の倍率。0 以下なら待たずに最速で流す
+}
+
+// NewFileSource は paths をデバイスとして扱う FileSource を返す。
+func NewFileSource(fps, speed float64, paths ...string) FrameSource {
+	if fps <= 0 {
+		fps = 30
+	}
+	return &FileSource{paths: paths, fps: fps, speed: speed}
+}
+
+func (s *FileSource) ListDevices() ([]Device, error) {
+	devs := make([]Device, len(s.paths))
+	for i, p := range s.paths {
+		devs[i] = Device{Index: i, Name: "ファイル (" + filepath.Base(p) + ")", ID: "file:" + p}
+	}
+	return devs, nil
+}
+
+func (s *FileSource) Open(ctx context.Context, deviceIndex int) (Stream, error) {
+	if deviceIndex < 0 || deviceIndex >= len(s.paths) {
+		return nil, fmt.Errorf("camera: device index %d out of range (0..%d)", deviceIndex, len(s.paths)-1)
+	}
+	reader, fps, err := openFrameReader(s.paths[deviceIndex])
+	if err != nil {
+		return nil, err
+	}
+	if fps <= 0 {
+		fps = s.fps
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan Frame, 1)
+	go s.run(ctx, reader, fps, out)
+	return NewChanStream(out, cancel), nil
+}
+
+func (s *FileSource) Capabilities() Capabilities {
+	return Capabilities{Supported: true, Live: false}
+}
+
+// run はファイルのフレームを順に送る。Timestamp は再生開始時刻 + 元のフレーム間隔 × 番号。
+func (s *FileSource) run(ctx context.Context, reader frameReader, fps float64, out chan<- Frame) {
+	defer close(out)
+	defer reader.Close()
+	interval := time.Duration(float64(time.Second) / fps)
+	start := time.Now()
+	for i := 0; ; i++ {
+		f, err := reader.Next()
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("camera: file: %v", err)
+			}
+			return
+		}
+		offset := time.Duration(i) * interval
+		f.Timestamp = start.Add(offset)
+		if s.speed > 0 {
+			wait := time.Until(start.Add(time.Duration(float64(offset) / s.speed)))
+			if wait > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+			}
+		}
+		select {
+		case out <- f:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// frameReader はファイル形式ごとのフレーム読み出し。終端では io.EOF を返す。
+type frameReader interface {
+	Next() (Frame, error)
+	Close() error
+}
+
+// openFrameReader は path の形式を判別して frameReader を返す。fps はファイルが持つフレームレート（不明なら 0）。
+func openFrameReader(path string) (frameReader, float64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("camera: file: %w", err)
+	}
+	if info.IsDir() {
+		r, err := newImageDirReader(path)
+		return r, 0, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("camera: file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".y4m":
+		r, err := newY4MReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, 0, err
+		}
+		return r, r.fps, nil
+	case ".mjpeg", ".mjpg":
+		return &mjpegFileReader{f: f, r: bufio.NewReader(f)}, 0, nil
+	default:
+		_ = f.Close()
+		return nil, 0, xerrors.Errorf("camera: file: unsupported format %q", path)
+	}
+}
+
+// imageDirReader はディレクトリ内の JPEG/PNG をファイル名順に読む。
+type imageDirReader struct {
+	files []string
+	next  int
+}
+
+func newImageDirReader(dir string) (*imageDirReader, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("camera: file: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".jpg", ".jpeg", ".png":
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, xerrors.Errorf("camera: file: no JPEG/PNG images in %s", dir)
+	}
+	sort.Strings(files)
+	return &imageDirReader{files: files}, nil
+}
+
+func (r *imageDirReader) Next() (Frame, error) {
+	if r.next >= len(r.files) {
+		return Frame{}, io.EOF
+	}
+	path := r.files[r.next]
+	r.next++
+	f, err := os.Open(path)
+	if err != nil {
+		return Frame{}, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return Frame{}, xerrors.Errorf("%s: %w", path, err)
+	}
+	return FrameFromImage(img), nil
+}
+
+func (r *imageDirReader) Close() error {
+	return nil
+}
@@ -0,0 +1,237 @@
+package camera
+
+import (
+	"bufio"
+	"bytes"
+	"image/jpeg"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// mjpegFileReader は JPEG を連結しただけの MJPEG ファイルを 1 枚ずつ読む。
+type mjpegFileReader struct {
+	f *os.File
+	r *bufio.Reader
+}
+
+func (r *mjpegFileReader) Next() (Frame, error) {
+	data, err := readJPEG(r.r)
+	if err != nil {
+		return Frame{}, err
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Frame{}, xerrors.Errorf("mjpeg: decode: %w", err)
+	}
+	return FrameFromImage(img), nil
+}
+
+func (r *mjpegFileReader) Close() error {
+	return r.f.Close()
+}
+
+// readJPEG は SOI から EOI までの 1 枚分のバイト列を切り出す。
+// マーカーセグメントは長さで読み飛ばすため、EXIF サムネイル内の EOI を誤検出しない。
+func readJPEG(r *bufio.Reader) ([]byte, error) {
+	// SOI (FF D8) まで読み飛ばす
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != 0xFF {
+			continue
+		}
+		next, err := r.ReadByte()
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if next == 0xD8 {
+			break
+		}
+		_ = r.UnreadByte()
+	}
+	buf := []byte{0xFF, 0xD8}
+	var marker byte
+	pending := false // エントロピー符号化データの直後で、既にマーカーを読み終えている
+	for {
+		if !pending {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if b != 0xFF {
+				return nil, xerrors.Errorf("mjpeg: expected marker, got 0x%02x", b)
+			}
+			if marker, err = readMarker(r); err != nil {
+				return nil, err
+			}
+		}
+		pending = false
+		buf = append(buf, 0xFF, marker)
+		switch {
+		case marker == 0xD9: // EOI
+			return buf, nil
+		case marker >= 0xD0 && marker <= 0xD7, marker == 0x01: // 長さを持たないマーカー
+			continue
+		}
+		var lenBytes [2]byte
+		if _, err := io.ReadFull(r, lenBytes[:]); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		n := int(lenBytes[0])<<8 | int(lenBytes[1])
+		if n < 2 {
+			return nil, xerrors.Errorf("mjpeg: invalid segment length %d", n)
+		}
+		seg := make([]byte, n-2)
+		if _, err := io.ReadFull(r, seg); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		buf = append(buf, lenBytes[:]...)
+		buf = append(buf, seg...)
+		if marker != 0xDA { // SOS 以外はそのまま次のマーカーへ
+			continue
+		}
+		// SOS の後はエントロピー符号化データ。FF 00 と RSTn 以外の FF xx が次のマーカー
+		for !pending {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if b != 0xFF {
+				buf = append(buf, b)
+				continue
+			}
+			next, err := readMarker(r)
+			if err != nil {
+				return nil, err
+			}
+			if next == 0x00 || (next >= 0xD0 && next <= 0xD7) {
+				buf = append(buf, b, next)
+				continue
+			}
+			marker, pending = next, true
+		}
+	}
+}
+
+// readMarker は FF の直後のマーカーコードを読む。フィルバイト（連続する FF）は読み飛ばす。
+func readMarker(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if b != 0xFF {
+			return b, nil
+		}
+	}
+}
+
+// y4mReader は YUV4MPEG2 ファイルを読む（C420* / C422 / C444 / Cmono に対応）。
+type y4mReader struct {
+	f      *os.File
+	r      *bufio.Reader
+	width  int
+	height int
+	fps    float64
+	chroma string
+	plane  []byte // 読み込み用。フレームごとに確保しないよう使い回す
+}
+
+func newY4MReader(f *os.File) (*y4mReader, error) {
+	r := bufio.NewReader(f)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, xerrors.Errorf("y4m: header: %w", err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) == 0 || fields[0] != "YUV4MPEG2" {
+		return nil, xerrors.New("y4m: not a YUV4MPEG2 file")
+	}
+	y := &y4mReader{f: f, r: r, chroma: "420"}
+	for _, field := range fields[1:] {
+		key, val := field[0], field[1:]
+		switch key {
+		case 'W':
+			y.width, _ = strconv.Atoi(val)
+		case 'H':
+			y.height, _ = strconv.Atoi(val)
+		case 'F':
+			if num, den, ok := strings.Cut(val, ":"); ok {
+				n, _ := strconv.ParseFloat(num, 64)
+				d, _ := strconv.ParseFloat(den, 64)
+				if n > 0 && d > 0 {
+					y.fps = n / d
+				}
+			}
+		case 'C':
+			y.chroma = val
+		}
+	}
+	if y.width <= 0 || y.height <= 0 {
+		return nil, xerrors.Errorf("y4m: invalid size %dx%d", y.width, y.height)
+	}
+	switch {
+	case strings.HasPrefix(y.chroma, "420"):
+		y.chroma = "420"
+	case y.chroma == "422", y.chroma == "444", y.chroma == "mono":
+	default:
+		return nil, xerrors.Errorf("y4m: unsupported colorspace C%s", y.chroma)
+	}
+	return y, nil
+}
+
+func (y *y4mReader) Next() (Frame, error) {
+	line, err := y.r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line == "" {
+			return Frame{}, io.EOF
+		}
+		return Frame{}, xerrors.Errorf("y4m: frame header: %w", err)
+	}
+	if !strings.HasPrefix(line, "FRAME") {
+		return Frame{}, xerrors.Errorf("y4m: unexpected frame header %q", strings.TrimSpace(line))
+	}
+	w, h := y.width, y.height
+	cw, ch := w, h
+	switch y.chroma {
+	case "420":
+		cw, ch = (w+1)/2, (h+1)/2
+	case "422":
+		cw = (w + 1) / 2
+	case "mono":
+		cw, ch = 0, 0
+	}
+	if n := w*h + 2*cw*ch; len(y.plane) != n {
+		y.plane = make([]byte, n)
+	}
+	plane := y.plane
+	if _, err := io.ReadFull(y.r, plane); err != nil {
+		return Frame{}, xerrors.Errorf("y4m: frame data: %w", err)
+	}
+	yp, cbp, crp := plane[:w*h], plane[w*h:w*h+cw*ch], plane[w*h+cw*ch:]
+	data := NewFrameBuffer(w * h * 3)
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			di := (py*w + px) * 3
+			data[di] = yp[py*w+px]
+			if cw == 0 {
+				data[di+1], data[di+2] = 128, 128
+				continue
+			}
+			ci := (py*ch/h)*cw + px*cw/w
+			data[di+1] = cbp[ci]
+			data[di+2] = crp[ci]
+		}
+	}
+	return Frame{Data: data, Width: w, Height: h}, nil
+}
+
+func (y *y4mReader) Close() error {
+	return y.f.Close()
+}
@@ -0,0 +1,47 @@
+package camera
+
+import (
+	"image"
+	"image/color"
+)
+
+// FrameFromImage はデコード済みの画像を YCbCr444 packed の Frame に変換する。
+func FrameFromImage(img image.Image) Frame {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	data := NewFrameBuffer(w * h * 3)
+	switch src := img.(type) {
+	case *image.YCbCr:
+		// JPEG デコード結果（4:2:0 など）はサブサンプリングを展開するだけで済む
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				di := (y*w + x) * 3
+				ci := src.COffset(b.Min.X+x, b.Min.Y+y)
+				data[di] = src.Y[src.YOffset(b.Min.X+x, b.Min.Y+y)]
+				data[di+1] = src.Cb[ci]
+				data[di+2] = src.Cr[ci]
+			}
+		}
+	default:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				c := color.YCbCrModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.YCbCr)
+				di := (y*w + x) * 3
+				data[di] = c.Y
+				data[di+1] = c.Cb
+				data[di+2] = c.Cr
+			}
+		}
+	}
+	return Frame{Data: data, Width: w, Height: h}
+}
+
+// Crop は (x, y) から w×h の範囲を切り抜いたフレームを返す。範囲はフレーム内に収まっていること。
+func (f Frame) Crop(x, y, w, h int) Frame {
+	data := NewFrameBuffer(w * h * 3)
+	for row := 0; row < h; row++ {
+		src := ((y+row)*f.Width + x) * 3
+		copy(data[row*w*3:(row+1)*w*3], f.Data[src:src+w*3])
+	}
+	return Frame{Data: data, Width: w, Height: h, Timestamp: f.Timestamp}
+}
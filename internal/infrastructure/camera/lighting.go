@@ -0,0 +1,135 @@
+package camera
+
+import "math"
+
+// LightingCondition はフレームの明るさから判定した撮影条件。
+type LightingCondition string
+
+const (
+	LightingOK          LightingCondition = "ok"
+	LightingTooDark     LightingCondition = "too_dark"    // 部屋が暗く顔の特徴が出ない
+	LightingBacklit     LightingCondition = "backlit"     // 背後の窓などが明るく、中央の顔が暗く潰れる
+	LightingOverexposed LightingCondition = "overexposed" // 白飛びしている
+)
+
+const (
+	lightingSampleStep   = 4   // 解析に使う画素の間隔（縦横とも）
+	lightingDarkLevel    = 40  // これ以下の Y を暗部とみなす
+	lightingBrightLevel  = 235 // これ以上の Y を白飛びとみなす
+	tooDarkMean          = 55
+	overexposedMean      = 200
+	overexposedRatio     = 0.35 // 白飛びした画素の割合がこれを超えたら白飛び
+	backlitSurroundMean  = 150  // 周辺がこれ以上明るく
+	backlitContrast      = 60   // かつ中央が周辺よりこれ以上暗ければ逆光
+	darkGamma            = 0.5
+	overexposedGamma     = 1.6
+	backlitTargetMean    = 128 // 逆光の補正で中央の平均をこの明るさに近づける
+	lightingCenterMargin = 4   // フレームの 1/4 ずつを周辺とし、残りを中央とする
+)
+
+// LightingStats は Y チャネルから求めた明るさの統計。値はすべて 0〜255 の輝度、割合は 0〜1。
+type LightingStats struct {
+	Mean         float64
+	CenterMean   float64 // 顔が写りやすい中央部分の平均
+	SurroundMean float64 // 中央以外（背景）の平均
+	DarkRatio    float64
+	BrightRatio  float64
+}
+
+// AnalyzeLighting は f の Y チャネルを間引きながら走査し、明るさの統計を返す。
+func AnalyzeLighting(f Frame) LightingStats {
+	var stats LightingStats
+	if f.Width <= 0 || f.Height <= 0 || len(f.Data) < f.Width*f.Height*3 {
+		return stats
+	}
+	x0, x1 := f.Width/lightingCenterMargin, f.Width-f.Width/lightingCenterMargin
+	y0, y1 := f.Height/lightingCenterMargin, f.Height-f.Height/lightingCenterMargin
+	var sum, centerSum, surroundSum float64
+	var n, centerN, surroundN, dark, bright int
+	for y := 0; y < f.Height; y += lightingSampleStep {
+		for x := 0; x < f.Width; x += lightingSampleStep {
+			v := f.Data[(y*f.Width+x)*3]
+			sum += float64(v)
+			n++
+			if x >= x0 && x < x1 && y >= y0 && y < y1 {
+				centerSum += float64(v)
+				centerN++
+			} else {
+				surroundSum += float64(v)
+				surroundN++
+			}
+			if v <= lightingDarkLevel {
+				dark++
+			} else if v >= lightingBrightLevel {
+				bright++
+			}
+		}
+	}
+	stats.Mean = sum / float64(n)
+	stats.CenterMean = stats.Mean
+	stats.SurroundMean = stats.Mean
+	if centerN > 0 {
+		stats.CenterMean = centerSum / float64(centerN)
+	}
+	if surroundN > 0 {
+		stats.SurroundMean = surroundSum / float64(surroundN)
+	}
+	stats.DarkRatio = float64(dark) / float64(n)
+	stats.BrightRatio = float64(bright) / float64(n)
+	return stats
+}
+
+// Condition は統計から撮影条件を判定する。
+func (s LightingStats) Condition() LightingCondition {
+	switch {
+	case s.Mean < tooDarkMean:
+		return LightingTooDark
+	case s.SurroundMean >= backlitSurroundMean && s.SurroundMean-s.CenterMean >= backlitContrast:
+		return LightingBacklit
+	case s.Mean > overexposedMean || s.BrightRatio > overexposedRatio:
+		return LightingOverexposed
+	}
+	return LightingOK
+}
+
+// CorrectLighting は条件に応じて Y チャネルだけを補正したフレームを返す（色差はそのまま）。
+// 暗い場合はガンマで持ち上げ、逆光は中央の平均が中間の明るさになるガンマをかけ、白飛びはガンマで抑える。
+// 背景はもともと明るいので、逆光の補正では背景より中央の暗部が大きく持ち上がる。
+func CorrectLighting(f Frame, s LightingStats) Frame {
+	var lut [256]byte
+	switch s.Condition() {
+	case LightingTooDark:
+		lut = gammaLUT(darkGamma)
+	case LightingOverexposed:
+		lut = gammaLUT(overexposedGamma)
+	case LightingBacklit:
+		lut = gammaLUT(backlitGamma(s.CenterMean))
+	default:
+		return f
+	}
+	data := NewFrameBuffer(len(f.Data))
+	copy(data, f.Data)
+	for i := 0; i+2 < len(data); i += 3 {
+		data[i] = lut[data[i]]
+	}
+	return Frame{Data: data, Width: f.Width, Height: f.Height, Timestamp: f.Timestamp}
+}
+
+func gammaLUT(gamma float64) [256]byte {
+	var lut [256]byte
+	for i := range lut {
+		lut[i] = byte(math.Round(255 * math.Pow(float64(i)/255, gamma)))
+	}
+	return lut
+}
+
+// backlitGamma は中央の平均 center を backlitTargetMean に持ち上げるガンマ値を返す。
+func backlitGamma(center float64) float64 {
+	if center < 1 {
+		return darkGamma
+	}
+	if center >= backlitTargetMean {
+		return 1
+	}
+	return math.Log(backlitTargetMean/255.0) / math.Log(center/255)
+}
@@ -6,16 +6,13 @@ import (
 
 // Device は利用可能なカメラデバイスを表す。
 type Device struct {
-	Index int    // 0-based index（StartCapture に渡す値）
+	Index int    // 0-based index（FrameSource.Open に渡す値）
 	Name  string // 表示名
-	ID    string // プラットフォーム固有の ID（macOS では未使用）
-}
-
-// ListDevices は利用可能なカメラデバイス一覧を返す。macOS のみ AVFoundation で一覧取得。
-func ListDevices() ([]Device, error) {
-	return listDevices()
+	ID    string // 抜き差ししても変わらないデバイスの ID（ソースごとに "avfoundation:" などの接頭辞を付ける）
+	Live  bool   // 実時間で流れるデバイスか（終端のある録画ファイルは false）
 }
 
+// listDevices は利用可能なカメラデバイス一覧を返す。macOS のみ AVFoundation で一覧取得。
 func listDevices() ([]Device, error) {
 	if runtime.GOOS == "darwin" {
 		return listDevicesDarwin()
@@ -24,5 +21,5 @@ func listDevices() ([]Device, error) {
 }
 
 func listDevicesDefault() ([]Device, error) {
-	return []Device{{Index: 0, Name: "デフォルトカメラ", ID: ""}}, nil
+	return []Device{{Index: 0, Name: "デフォルトカメラ", ID: "default"}}, nil
 }
@@ -0,0 +1,175 @@
+package camera
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	mjpegDialTimeout    = 5 * time.Second
+	mjpegMinBackoff     = 500 * time.Millisecond
+	mjpegMaxBackoff     = 10 * time.Second
+	mjpegMaxFrameLength = 10 * 1024 * 1024
+)
+
+// MJPEGSource は multipart/x-mixed-replace で配信される MJPEG（スマホ・IP Webカメラ）を取り込む FrameSource。
+// 接続が切れた場合はバックオフしながら再接続し続ける。
+type MJPEGSource struct {
+	urls   []string
+	client *http.Client
+}
+
+// NewMJPEGSource は設定されたストリーム URL をデバイスとして扱う MJPEGSource を返す。
+func NewMJPEGSource(urls ...string) FrameSource {
+	return &MJPEGSource{
+		urls: urls,
+		client: &http.Client{
+			// ストリームは終わらないため全体のタイムアウトは付けず、接続とヘッダー受信のみ制限する
+			Transport: &http.Transport{
+				DialContext:           (&net.Dialer{Timeout: mjpegDialTimeout}).DialContext,
+				ResponseHeaderTimeout: mjpegDialTimeout,
+			},
+		},
+	}
+}
+
+func (s *MJPEGSource) ListDevices() ([]Device, error) {
+	devs := make([]Device, len(s.urls))
+	for i, u := range s.urls {
+		name := mjpegDisplayURL(u)
+		if parsed, err := url.Parse(u); err == nil && parsed.Host != "" {
+			name = parsed.Host
+		}
+		devs[i] = Device{Index: i, Name: "ネットワークカメラ (" + name + ")", ID: mjpegDeviceID(u), Live: true}
+	}
+	return devs, nil
+}
+
+// mjpegDeviceID は URL から作るデバイス ID。設定に保存されフロントにも送られるため、
+// URL に含まれる認証情報やトークンが出ないようハッシュにする。
+func mjpegDeviceID(streamURL string) string {
+	sum := sha256.Sum256([]byte(streamURL))
+	return "mjpeg:" + hex.EncodeToString(sum[:8])
+}
+
+// withoutURL は *url.Error から URL を除いた元のエラーを返す（ログに認証情報やトークンを出さないため）。
+func withoutURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
+
+// mjpegDisplayURL はログ・表示用に、認証情報とクエリ（トークンを含むことがある）を除いた URL を返す。
+func mjpegDisplayURL(streamURL string) string {
+	parsed, err := url.Parse(streamURL)
+	if err != nil {
+		return "(invalid url)"
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+func (s *MJPEGSource) Open(ctx context.Context, deviceIndex int) (Stream, error) {
+	if deviceIndex < 0 || deviceIndex >= len(s.urls) {
+		return nil, fmt.Errorf("camera: device index %d out of range (0..%d)", deviceIndex, len(s.urls)-1)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan Frame, 1)
+	go s.run(ctx, s.urls[deviceIndex], out)
+	return NewChanStream(out, cancel), nil
+}
+
+func (s *MJPEGSource) Capabilities() Capabilities {
+	return Capabilities{Supported: true, Live: true}
+}
+
+// run は ctx が終わるまで接続・受信・再接続を繰り返す。
+func (s *MJPEGSource) run(ctx context.Context, streamURL string, out chan<- Frame) {
+	defer close(out)
+	backoff := mjpegMinBackoff
+	for {
+		received, err := s.receive(ctx, streamURL, out)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			backoff = mjpegMinBackoff
+		}
+		log.Printf("camera: mjpeg %s: %v (reconnecting in %s)", mjpegDisplayURL(streamURL), err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, mjpegMaxBackoff)
+	}
+}
+
+// receive は 1 回の接続でフレームを受信し続ける。1 フレームでも受信できたら received を true にする。
+func (s *MJPEGSource) receive(ctx context.Context, streamURL string, out chan<- Frame) (received bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return false, xerrors.Errorf("new request: %w", withoutURL(err))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, xerrors.Errorf("request: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, xerrors.Errorf("server returned %d", resp.StatusCode)
+	}
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false, xerrors.Errorf("content type: %w", err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return false, xerrors.Errorf("unexpected content type %q", mediaType)
+	}
+	// 多くのカメラは boundary パラメータ自体に "--" を含めて送ってくる
+	mr := multipart.NewReader(resp.Body, strings.TrimPrefix(params["boundary"], "--"))
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return received, xerrors.Errorf("next part: %w", err)
+		}
+		// ネットワークカメラは撮影時刻を送らないため、フレームが届き始めた時刻を使う
+		arrived := time.Now()
+		img, err := jpeg.Decode(io.LimitReader(part, mjpegMaxFrameLength))
+		_ = part.Close()
+		if err != nil {
+			continue
+		}
+		received = true
+		f := FrameFromImage(img)
+		f.Timestamp = arrived
+		select {
+		case out <- f:
+		case <-ctx.Done():
+			f.Release()
+			return received, ctx.Err()
+		default:
+			// 処理が追いつかない場合はドロップ
+			f.Release()
+		}
+	}
+}
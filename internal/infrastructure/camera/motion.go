@@ -0,0 +1,99 @@
+package camera
+
+import "math"
+
+const (
+	motionStep       = 4  // 縦横この間隔で間引いた Y で比べる
+	motionPixelDelta = 24 // これ以上 Y が変わった画素を「変化した」とみなす
+	motionMaxShift   = 16 // 上下のずれを探す範囲（行数）
+)
+
+// Motion は直前のフレームからの動きの大きさ。
+type Motion struct {
+	Changed  float64 // 明るさが大きく変わった画素の割合（0〜1）。人の出入りなど
+	Vertical float64 // 中央部分の上下のずれ。フレームの高さに対する割合で、下向きが正
+}
+
+// MotionEstimator は連続するフレームの Y を差分して動きを見積もる。顔検出より桁違いに軽く、毎フレーム呼べる。
+// タイピングのように手元だけが動く場合は Vertical がほぼ 0 になり、しゃがむと中央の行の明るさの並びが上下にずれる。
+// 並行には使えない。
+type MotionEstimator struct {
+	prev, cur         []byte    // 間引いた Y
+	prevRows, curRows []float64 // 中央の列だけの、行ごとの Y の平均（ずれを 1 行単位で求めるため行は間引かない）
+	width, height     int       // 前回のフレームの解像度
+}
+
+// Update は f を前回のフレームと比べた動きを返す。最初のフレームや解像度が変わった直後は動きなしとする。
+func (m *MotionEstimator) Update(f Frame) Motion {
+	if f.Width <= 0 || f.Height <= 0 || len(f.Data) < f.Width*f.Height*3 {
+		return Motion{}
+	}
+	w, h := (f.Width+motionStep-1)/motionStep, (f.Height+motionStep-1)/motionStep
+	resized := f.Width != m.width || f.Height != m.height
+	if resized {
+		m.width, m.height = f.Width, f.Height
+		m.prev, m.cur = make([]byte, w*h), make([]byte, w*h)
+		m.prevRows, m.curRows = make([]float64, f.Height), make([]float64, f.Height)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			m.cur[y*w+x] = f.Data[((y*motionStep)*f.Width+x*motionStep)*3]
+		}
+	}
+	x0, x1 := f.Width/4, f.Width-f.Width/4
+	for y := 0; y < f.Height; y++ {
+		sum, n := 0, 0
+		for x := x0; x < x1; x += motionStep {
+			sum += int(f.Data[(y*f.Width+x)*3])
+			n++
+		}
+		m.curRows[y] = float64(sum) / float64(max(1, n))
+	}
+	defer func() {
+		m.prev, m.cur = m.cur, m.prev
+		m.prevRows, m.curRows = m.curRows, m.prevRows
+	}()
+	if resized {
+		return Motion{}
+	}
+
+	changed := 0
+	for i, v := range m.cur {
+		d := int(v) - int(m.prev[i])
+		if d >= motionPixelDelta || d <= -motionPixelDelta {
+			changed++
+		}
+	}
+	shift := rowShift(m.prevRows, m.curRows, min(motionMaxShift, f.Height/4))
+	return Motion{
+		Changed:  float64(changed) / float64(len(m.cur)),
+		Vertical: float64(shift) / float64(f.Height),
+	}
+}
+
+// rowShift は prev を何行ずらすと cur に最もよく重なるかを返す。
+// 明るさの並びが平坦だとどのずれでも差が変わらないため、ずれなしより 1 割以上よく重なる場合だけずれとみなす。
+func rowShift(prev, cur []float64, maxShift int) int {
+	best, bestErr := 0, rowDiff(prev, cur, 0)
+	for s := 1; s <= maxShift; s++ {
+		for _, shift := range [2]int{s, -s} {
+			if e := rowDiff(prev, cur, shift); e < bestErr*0.9 {
+				best, bestErr = shift, e
+			}
+		}
+	}
+	return best
+}
+
+// rowDiff は cur[i] と prev[i-shift] の重なる範囲での差の絶対値の平均。
+func rowDiff(prev, cur []float64, shift int) float64 {
+	sum, n := 0.0, 0
+	for i := max(0, shift); i < len(cur) && i-shift < len(prev); i++ {
+		sum += math.Abs(cur[i] - prev[i-shift])
+		n++
+	}
+	if n == 0 {
+		return math.Inf(1)
+	}
+	return sum / float64(n)
+}
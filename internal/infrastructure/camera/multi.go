@@ -0,0 +1,66 @@
+package camera
+
+import (
+	"context"
+	"fmt"
+)
+
+// MultiSource は複数の FrameSource をひとつのデバイス一覧として扱う。
+// インデックスは各ソースのデバイスを順に連結したもの。この環境で使えないソースは一覧に含めない。
+type MultiSource struct {
+	sources []FrameSource
+}
+
+func NewMultiSource(sources ...FrameSource) FrameSource {
+	return &MultiSource{sources: sources}
+}
+
+func (m *MultiSource) ListDevices() ([]Device, error) {
+	var out []Device
+	for _, src := range m.supported() {
+		devs, err := src.ListDevices()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range devs {
+			d.Index = len(out)
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (m *MultiSource) Open(ctx context.Context, deviceIndex int) (Stream, error) {
+	offset := 0
+	for _, src := range m.supported() {
+		devs, err := src.ListDevices()
+		if err != nil {
+			return nil, err
+		}
+		if deviceIndex < offset+len(devs) {
+			return src.Open(ctx, deviceIndex-offset)
+		}
+		offset += len(devs)
+	}
+	return nil, fmt.Errorf("camera: device index %d out of range (0..%d)", deviceIndex, offset-1)
+}
+
+func (m *MultiSource) Capabilities() Capabilities {
+	caps := Capabilities{}
+	for _, src := range m.supported() {
+		c := src.Capabilities()
+		caps.Supported = true
+		caps.Live = caps.Live || c.Live
+	}
+	return caps
+}
+
+func (m *MultiSource) supported() []FrameSource {
+	var out []FrameSource
+	for _, src := range m.sources {
+		if src.Capabilities().Supported {
+			out = append(out, src)
+		}
+	}
+	return out
+}
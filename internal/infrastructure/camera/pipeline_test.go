@@ -0,0 +1,35 @@
+package camera
+
+import (
+	"testing"
+
+	"github.com/kikils/desk-squat-tracker/internal/utils"
+)
+
+// BenchmarkFramePipeline はキャプチャ 1 フレーム分の処理（ソースのバッファからのコピー → 回転・縮小 → JPEG）を測る。
+// ソースは 640x480、変換は既定の取り込み解像度（352x288）への縮小と 180 度の回転。
+func BenchmarkFramePipeline(b *testing.B) {
+	const w, h = 640, 480
+	src := make([]byte, w*h*3)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := (y*w + x) * 3
+			src[i], src[i+1], src[i+2] = byte(x^y), byte(x/3), byte(y/2)
+		}
+	}
+	t := Transform{Width: 352, Height: 288, Rotation: 180}
+	var enc utils.JPEGEncoder
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		data := NewFrameBuffer(len(src))
+		copy(data, src)
+		f := Frame{Data: data, Width: w, Height: h}
+		out := t.Apply(f)
+		f.Release()
+		if _, err := enc.Encode(utils.Frame{Data: out.Data, Width: out.Width, Height: out.Height}, 75); err != nil {
+			b.Fatal(err)
+		}
+		out.Release()
+	}
+}
@@ -0,0 +1,30 @@
+package camera
+
+import "sync"
+
+// framePools はフレームのバッファをサイズごとに使い回す。30fps で毎フレーム確保すると GC の負荷が大きいため、
+// キャプチャ・変換・切り抜きはプールから取り出し、フレームを最後に使った側が Release で返す。
+// 同じストリームのフレームは同じサイズになるので、サイズごとに分けておけばほぼ確実に再利用できる。
+var framePools sync.Map // int -> *sync.Pool
+
+// NewFrameBuffer は長さ size のバッファをプールから取り出す（なければ確保する）。
+// 前に使われた内容が残っているので、呼び出し側で全体を書き換えること。
+func NewFrameBuffer(size int) []byte {
+	if p, ok := framePools.Load(size); ok {
+		if b, ok := p.(*sync.Pool).Get().(*[]byte); ok {
+			return *b
+		}
+	}
+	return make([]byte, size)
+}
+
+// Release は f のバッファをプールに返す。呼んだ後は f のデータ（切り抜き前の元フレームなども含む）を使わないこと。
+// どのソースのフレームでも呼んでよい。
+func (f Frame) Release() {
+	if len(f.Data) == 0 {
+		return
+	}
+	p, _ := framePools.LoadOrStore(len(f.Data), &sync.Pool{})
+	b := f.Data
+	p.(*sync.Pool).Put(&b)
+}
@@ -2,8 +2,8 @@ package camera
 
 import (
 	"context"
-	"fmt"
-	"runtime"
+	"sync"
+	"time"
 )
 
 // Frame は 1 フレーム（YCbCr444 packed、Width*Height*3 バイト）。
@@ -11,16 +11,57 @@ type Frame struct {
 	Data   []byte
 	Width  int
 	Height int
+	// Timestamp は撮影時刻。time.Now を基準にした単調増加の読み（monotonic clock）を持つため、
+	// 差を取ればフレーム間隔やレップの所要時間を正確に求められる。ゼロ値なら未設定
+	Timestamp time.Time
 }
 
-// StartStream は指定デバイスでキャプチャを開始し、フレームチャネルを返す。macOS のみ対応。
-func StartStream(ctx context.Context, deviceIndex int) (<-chan Frame, error) {
-	if runtime.GOOS != "darwin" {
-		return nil, fmt.Errorf("camera: unsupported platform %s", runtime.GOOS)
-	}
-	devs, _ := ListDevices()
-	if deviceIndex < 0 || deviceIndex >= len(devs) {
-		return nil, fmt.Errorf("camera: device index %d out of range (0..%d)", deviceIndex, len(devs)-1)
-	}
-	return startStreamDarwin(ctx, deviceIndex)
+// Capabilities はフレームソースの特性。
+type Capabilities struct {
+	Supported bool // この環境で Open できるか
+	Live      bool // 実時間で流れるソースか（録画ファイルの再生などは false）
+}
+
+// Stream は Open したデバイスから流れるフレーム列。
+type Stream interface {
+	// Frames はフレームを受け取るチャネルを返す。Close やデバイスの切断・終端で close される。
+	Frames() <-chan Frame
+	// Close はキャプチャを止め、後片付けが終わるまで待つ。
+	Close() error
+}
+
+// FrameSource はフレームの供給元（カメラ、ネットワーク、ファイルなど）。
+type FrameSource interface {
+	// ListDevices は Open に渡せるデバイス一覧を返す。
+	ListDevices() ([]Device, error)
+	// Open は指定したデバイスからのキャプチャを開始する。ctx が終わるとストリームも終了する。
+	Open(ctx context.Context, deviceIndex int) (Stream, error)
+	Capabilities() Capabilities
+}
+
+// chanStream は goroutine がフレームを書き込み、終了時に close するチャネルを Stream として扱う。
+type chanStream struct {
+	frames    <-chan Frame
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// NewChanStream は frames を Stream として返す。書き込み側は ctx の終了時に frames を close すること。
+// Close は cancel を呼び、frames が close されるまで待つ。
+func NewChanStream(frames <-chan Frame, cancel context.CancelFunc) Stream {
+	return &chanStream{frames: frames, cancel: cancel}
+}
+
+func (s *chanStream) Frames() <-chan Frame {
+	return s.frames
+}
+
+func (s *chanStream) Close() error {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		// 書き込み側の goroutine がチャネルを close するまで待つ（後片付けの完了を保証する）
+		for range s.frames {
+		}
+	})
+	return nil
 }
@@ -0,0 +1,17 @@
+//go:build !darwin
+
+package camera
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+func listDevicesDarwin() ([]Device, error) {
+	return listDevicesDefault()
+}
+
+func startStreamDarwin(ctx context.Context, deviceIndex int) (<-chan Frame, error) {
+	return nil, fmt.Errorf("camera: unsupported platform %s", runtime.GOOS)
+}
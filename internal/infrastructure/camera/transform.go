@@ -0,0 +1,187 @@
+package camera
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Transform はソースに依存しないフレームの後処理（回転・左右反転・解像度・フレームレート）。
+type Transform struct {
+	Width    int     // 目標の解像度。縦横比を保ったままこの枠に収まるよう縮小する（0 なら縮小しない）
+	Height   int     // 縦長のフレームでは Width と Height を入れ替えた枠に収める
+	MaxFPS   float64 // 0 なら間引かない
+	Rotation int     // 時計回りの回転角（0 / 90 / 180 / 270）
+	Mirror   bool    // 回転後に左右反転する
+}
+
+// Apply は f に回転・左右反転・縮小を 1 回の走査でまとめて適用する。何も変わらない場合は f をそのまま返す。
+func (t Transform) Apply(f Frame) Frame {
+	if f.Width <= 0 || f.Height <= 0 || len(f.Data) < f.Width*f.Height*3 {
+		return f
+	}
+	rotation := ((t.Rotation % 360) + 360) % 360 / 90 * 90
+	rw, rh := f.Width, f.Height
+	if rotation == 90 || rotation == 270 {
+		rw, rh = rh, rw
+	}
+	dw, dh := t.fit(rw, rh)
+	if rotation == 0 && !t.Mirror && dw == f.Width && dh == f.Height {
+		return f
+	}
+
+	data := NewFrameBuffer(dw * dh * 3)
+	for dy := 0; dy < dh; dy++ {
+		ry := dy * rh / dh
+		for dx := 0; dx < dw; dx++ {
+			rx := dx * rw / dw
+			if t.Mirror {
+				rx = rw - 1 - rx
+			}
+			// 回転後の座標 (rx, ry) を元フレームの座標に戻す
+			var sx, sy int
+			switch rotation {
+			case 90:
+				sx, sy = ry, f.Height-1-rx
+			case 180:
+				sx, sy = f.Width-1-rx, f.Height-1-ry
+			case 270:
+				sx, sy = f.Width-1-ry, rx
+			default:
+				sx, sy = rx, ry
+			}
+			copy(data[(dy*dw+dx)*3:(dy*dw+dx)*3+3], f.Data[(sy*f.Width+sx)*3:])
+		}
+	}
+	return Frame{Data: data, Width: dw, Height: dh, Timestamp: f.Timestamp}
+}
+
+// fit は w×h を縦横比を保ったまま目標の枠に収めたサイズを返す。拡大はしない。
+func (t Transform) fit(w, h int) (int, int) {
+	bw, bh := t.Width, t.Height
+	if bw <= 0 || bh <= 0 {
+		return w, h
+	}
+	// 枠の向きをフレームの向きに合わせる（縦長のカメラでも短辺・長辺で比べる）
+	if (w < h) != (bw < bh) {
+		bw, bh = bh, bw
+	}
+	scale := min(float64(bw)/float64(w), float64(bh)/float64(h), 1)
+	return max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5))
+}
+
+// TransformSource は内側の FrameSource が流すフレームに Transform を適用する FrameSource。
+// どのソースでも同じ後処理になるよう、カメラ・ネットワーク・ファイルなどの外側に被せて使う。
+type TransformSource struct {
+	source    FrameSource
+	transform atomic.Pointer[Transform]
+
+	pushMu   sync.Mutex
+	pushRate frameRate // Push で受け取るフレームの間引き
+}
+
+func NewTransformSource(source FrameSource) *TransformSource {
+	s := &TransformSource{source: source}
+	s.transform.Store(&Transform{})
+	return s
+}
+
+// SetTransform は後処理を差し替える。キャプチャ中のストリームにも次のフレームから反映される。
+func (s *TransformSource) SetTransform(t Transform) {
+	s.transform.Store(&t)
+}
+
+func (s *TransformSource) ListDevices() ([]Device, error) {
+	return s.source.ListDevices()
+}
+
+func (s *TransformSource) Open(ctx context.Context, deviceIndex int) (Stream, error) {
+	inner, err := s.source.Open(ctx, deviceIndex)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan Frame, 1)
+	go s.run(ctx, inner, out)
+	return NewChanStream(out, cancel), nil
+}
+
+func (s *TransformSource) Capabilities() Capabilities {
+	return s.source.Capabilities()
+}
+
+func (s *TransformSource) run(ctx context.Context, inner Stream, out chan<- Frame) {
+	defer close(out)
+	defer inner.Close()
+	var rate frameRate
+	for {
+		var f Frame
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return
+		case f, ok = <-inner.Frames():
+			if !ok {
+				return
+			}
+		}
+		g, ok := s.process(&rate, f)
+		if !ok {
+			continue
+		}
+		select {
+		case out <- g:
+		case <-ctx.Done():
+			g.Release()
+			return
+		}
+	}
+}
+
+// Push はストリームを通らずに届いたフレーム（ブラウザの getUserMedia など）に、Open で開いたストリームと同じ
+// 間引き・変換を適用する。間引いたときは f を返却して false を返す。
+func (s *TransformSource) Push(f Frame) (Frame, bool) {
+	s.pushMu.Lock()
+	defer s.pushMu.Unlock()
+	return s.process(&s.pushRate, f)
+}
+
+// process は f に現在の Transform を適用する。MaxFPS を超えて届いたフレームは返却して false を返す。
+func (s *TransformSource) process(rate *frameRate, f Frame) (Frame, bool) {
+	t := *s.transform.Load()
+	if !rate.allow(f.Timestamp, t.MaxFPS) {
+		f.Release()
+		return Frame{}, false
+	}
+	g := t.Apply(f)
+	if len(g.Data) > 0 && len(f.Data) > 0 && &g.Data[0] != &f.Data[0] {
+		// 変換後のフレームは別のバッファなので、元のフレームはここで返す
+		f.Release()
+	}
+	return g, true
+}
+
+// frameRate は MaxFPS に合わせてフレームを間引くための、次に通す時刻。
+type frameRate struct {
+	next time.Time
+}
+
+// allow は ts のフレームを通すかを返す。maxFPS が 0 なら常に通す。
+func (r *frameRate) allow(ts time.Time, maxFPS float64) bool {
+	if maxFPS <= 0 {
+		return true
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	interval := time.Duration(float64(time.Second) / maxFPS)
+	// 到着のゆらぎで 1 フレーム余分に捨てないよう、間隔の 1 割までは早く来ても通す
+	if !r.next.IsZero() && ts.Before(r.next.Add(-interval/10)) {
+		return false
+	}
+	if r.next = r.next.Add(interval); r.next.Before(ts) {
+		r.next = ts.Add(interval)
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package camera
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransformSource_Push(t *testing.T) {
+	s := NewTransformSource(nil)
+	s.SetTransform(Transform{Rotation: 90, MaxFPS: 10})
+
+	at := time.Now()
+	push := func(offset time.Duration) (Frame, bool) {
+		return s.Push(Frame{Data: NewFrameBuffer(4 * 2 * 3), Width: 4, Height: 2, Timestamp: at.Add(offset)})
+	}
+
+	// ネイティブのソースと同じく回転される
+	f, ok := push(0)
+	if !ok {
+		t.Fatal("first frame was dropped")
+	}
+	if f.Width != 2 || f.Height != 4 {
+		t.Errorf("pushed frame is %dx%d, want 2x4 after rotating 90 degrees", f.Width, f.Height)
+	}
+	f.Release()
+
+	// MaxFPS を超えて届いたフレームは間引く
+	if _, ok := push(30 * time.Millisecond); ok {
+		t.Error("frame 30ms after the previous one passed at MaxFPS 10")
+	}
+	if f, ok := push(100 * time.Millisecond); !ok {
+		t.Error("frame 100ms after the previous one was dropped at MaxFPS 10")
+	} else {
+		f.Release()
+	}
+}
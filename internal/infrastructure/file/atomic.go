@@ -0,0 +1,34 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomic は data を path に 0600 で書き込む。書き込み途中で落ちても path が壊れないよう、
+// 同じディレクトリの一時ファイルに書いて Sync してから rename で置き換える。
+func WriteAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // rename 済みなら何もしない
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,75 @@
+package file
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kikils/desk-squat-tracker/internal/config"
+)
+
+const (
+	sessionsDirname      = "sessions"
+	webhookQueueFilename = "webhook_queue.json"
+	serverTokenFilename  = "server_token"
+)
+
+// appConfigDir はアプリ用の設定ディレクトリ（UserConfigDir/desk-squat-tracker）を作成して返す。
+func appConfigDir() (string, error) {
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(userConfigDir, config.AppName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// SessionDir はキャプチャセッションの記録先ディレクトリを返す。
+func SessionDir() (string, error) {
+	dir, err := appConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sessionsDirname), nil
+}
+
+// WebhookQueuePath は送信待ちの Webhook を保存するファイルのパスを返す。
+func WebhookQueuePath() (string, error) {
+	dir, err := appConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, webhookQueueFilename), nil
+}
+
+// ServerToken はヘッドレス起動の画面を開くためのトークンを返す。初回に生成し、所有者だけが読めるファイルに保存する。
+func ServerToken() (string, error) {
+	dir, err := appConfigDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, serverTokenFilename)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate server token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
@@ -6,7 +6,6 @@ import (
 	"path/filepath"
 	"sync"
 
-	"github.com/kikils/desk-squat-tracker/internal/config"
 	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
 	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
 )
@@ -19,14 +18,10 @@ type SettingRepository struct {
 }
 
 func NewSettingRepository() (repository.SettingRepository, error) {
-	userConfigDir, err := os.UserConfigDir()
+	dir, err := appConfigDir()
 	if err != nil {
 		return nil, err
 	}
-	dir := filepath.Join(userConfigDir, config.AppName)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return nil, err
-	}
 	return &SettingRepository{
 		path: filepath.Join(dir, settingsFilename),
 	}, nil
@@ -35,7 +30,31 @@ func NewSettingRepository() (repository.SettingRepository, error) {
 func (r *SettingRepository) Get() (*entity.Setting, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	return r.load()
+}
+
+func (r *SettingRepository) Save(s *entity.Setting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store(s)
+}
+
+func (r *SettingRepository) Update(fn func(s *entity.Setting) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(s); err != nil {
+		return err
+	}
+	return r.store(s)
+}
 
+// load は r.mu を保持した状態で呼ぶ
+func (r *SettingRepository) load() (*entity.Setting, error) {
 	data, err := os.ReadFile(r.path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -44,7 +63,8 @@ func (r *SettingRepository) Get() (*entity.Setting, error) {
 		return nil, err
 	}
 
-	var s entity.Setting
+	// 古い設定ファイルに無い項目はデフォルトのまま残す
+	s := *entity.DefaultSetting()
 	if err := json.Unmarshal(data, &s); err != nil {
 		return nil, err
 	}
@@ -60,17 +80,38 @@ func (r *SettingRepository) Get() (*entity.Setting, error) {
 		s.TopRatio = def.TopRatio
 		s.BottomRatio = def.BottomRatio
 	}
+	if s.CaptureWidth < 0 || s.CaptureHeight < 0 {
+		s.CaptureWidth = def.CaptureWidth
+		s.CaptureHeight = def.CaptureHeight
+	}
+	if s.MaxFPS < 0 {
+		s.MaxFPS = 0
+	} else if s.MaxFPS > 0 && s.MaxFPS < 1 {
+		s.MaxFPS = 1
+	}
+	if !entity.ValidRotation(s.Rotation) {
+		s.Rotation = 0
+	}
+	if !s.ROI.Valid() {
+		s.ROI = entity.Region{}
+	}
+	if !entity.ValidPort(s.API.Port) {
+		s.API.Port = def.API.Port
+	}
+	if s.DailyGoal < 0 {
+		s.DailyGoal = 0
+	}
+	if s.MQTT.TopicPrefix == "" {
+		s.MQTT.TopicPrefix = def.MQTT.TopicPrefix
+	}
 	return &s, nil
 }
 
-func (r *SettingRepository) Save(s *entity.Setting) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
+// store は r.mu を保持した状態で呼ぶ。書き込み途中で落ちても settings.json が壊れないよう、WriteAtomic で置き換える。
+func (r *SettingRepository) store(s *entity.Setting) error {
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
 	}
-
-	return os.WriteFile(r.path, data, 0600)
+	return WriteAtomic(r.path, data)
 }
@@ -47,3 +47,18 @@ func (r *JudgementRepository) CountRepsByDate(date civil.Date) (int, error) {
 	}
 	return count, nil
 }
+
+func (r *JudgementRepository) CountRepsByDateRange(from, to civil.Date) (map[civil.Date]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[civil.Date]int)
+	for _, judgement := range r.judgements {
+		if !judgement.IsRepCompleted {
+			continue
+		}
+		if date := judgement.Date(); !date.Before(from) && !date.After(to) {
+			counts[date]++
+		}
+	}
+	return counts, nil
+}
@@ -22,18 +22,25 @@ func (r *SettingRepository) Get() (*entity.Setting, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	// コピーを返す
-	return &entity.Setting{
-		TopRatio:    r.setting.TopRatio,
-		BottomRatio: r.setting.BottomRatio,
-	}, nil
+	copied := *r.setting
+	return &copied, nil
 }
 
 func (r *SettingRepository) Save(s *entity.Setting) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.setting = &entity.Setting{
-		TopRatio:    s.TopRatio,
-		BottomRatio: s.BottomRatio,
+	copied := *s
+	r.setting = &copied
+	return nil
+}
+
+func (r *SettingRepository) Update(fn func(s *entity.Setting) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *r.setting
+	if err := fn(&copied); err != nil {
+		return err
 	}
+	r.setting = &copied
 	return nil
 }
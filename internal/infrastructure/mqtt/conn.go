@@ -0,0 +1,244 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// MQTT 3.1.1 の制御パケットの種類（固定ヘッダの上位 4 ビット）。
+const (
+	packetConnect    = 1
+	packetConnAck    = 2
+	packetPublish    = 3
+	packetPingReq    = 12
+	packetPingResp   = 13
+	packetDisconnect = 14
+)
+
+const (
+	dialTimeout  = 10 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+// connAckErrors は CONNACK の戻りコードの意味。
+var connAckErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// message は PUBLISH する 1 件。QoS はすべて 0（値は retained で残るので、取りこぼしても次の更新か再接続で追いつく）。
+type message struct {
+	Topic   string
+	Payload string
+	Retain  bool
+}
+
+// connectOptions は CONNECT で送る内容。
+type connectOptions struct {
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration
+	Will      *message // 切断を検知したときにブローカーが送るメッセージ
+}
+
+// conn はブローカーとの 1 本の接続。QoS 0 の PUBLISH と PINGREQ だけを送る最小限の MQTT 3.1.1 クライアント。
+// 書き込みは 1 つのゴルーチンから、読み込みは readLoop から行う。
+type conn struct {
+	nc net.Conn
+	r  *bufio.Reader
+
+	mu sync.Mutex // 書き込み
+}
+
+// dial はブローカーにつなぎ、CONNACK を受け取るまで待つ。
+func dial(ctx context.Context, broker string, opts connectOptions) (*conn, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return nil, fmt.Errorf("mqtt: %w", err)
+	}
+	secure := u.Scheme == "ssl" || u.Scheme == "tls" || u.Scheme == "mqtts"
+	addr := u.Host
+	if u.Port() == "" {
+		if secure {
+			addr = net.JoinHostPort(u.Hostname(), "8883")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "1883")
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	var nc net.Conn
+	if secure {
+		d := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+		nc, err = d.DialContext(ctx, "tcp", addr)
+	} else {
+		var d net.Dialer
+		nc, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mqtt: dial %s: %w", addr, err)
+	}
+	c := &conn{nc: nc, r: bufio.NewReader(nc)}
+	if err := c.connect(ctx, opts); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *conn) connect(ctx context.Context, opts connectOptions) error {
+	var flags byte = 0x02 // clean session
+	var payload []byte
+	payload = appendString(payload, opts.ClientID)
+	if opts.Will != nil {
+		flags |= 0x04
+		if opts.Will.Retain {
+			flags |= 0x20
+		}
+		payload = appendString(payload, opts.Will.Topic)
+		payload = appendString(payload, opts.Will.Payload)
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, opts.Username)
+		if opts.Password != "" {
+			flags |= 0x40
+			payload = appendString(payload, opts.Password)
+		}
+	}
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4, flags) // プロトコルレベル 4 = 3.1.1
+	body = binary.BigEndian.AppendUint16(body, uint16(opts.KeepAlive/time.Second))
+	body = append(body, payload...)
+	if err := c.write(packetConnect<<4, body); err != nil {
+		return err
+	}
+
+	deadline, _ := ctx.Deadline()
+	c.nc.SetReadDeadline(deadline)
+	defer c.nc.SetReadDeadline(time.Time{})
+	typ, ack, err := c.read()
+	if err != nil {
+		return fmt.Errorf("mqtt: read connack: %w", err)
+	}
+	if typ != packetConnAck || len(ack) != 2 {
+		return fmt.Errorf("mqtt: expected connack, got packet type %d", typ)
+	}
+	if code := ack[1]; code != 0 {
+		if msg, ok := connAckErrors[code]; ok {
+			return fmt.Errorf("mqtt: connection refused: %s", msg)
+		}
+		return fmt.Errorf("mqtt: connection refused: code %d", code)
+	}
+	return nil
+}
+
+func (c *conn) publish(m message) error {
+	var header byte = packetPublish << 4
+	if m.Retain {
+		header |= 0x01
+	}
+	body := appendString(nil, m.Topic)
+	body = append(body, m.Payload...)
+	return c.write(header, body)
+}
+
+func (c *conn) ping() error {
+	return c.write(packetPingReq<<4, nil)
+}
+
+// close は DISCONNECT を送ってから切断する。DISCONNECT を受けたブローカーは Will を送らない。
+func (c *conn) close() error {
+	err := c.write(packetDisconnect<<4, nil)
+	c.nc.Close()
+	return err
+}
+
+// readLoop は接続が切れるまでパケットを読み、受け取るたびに onPacket を呼ぶ（PINGRESP などの中身は使わない）。
+func (c *conn) readLoop(onPacket func()) error {
+	for {
+		if _, _, err := c.read(); err != nil {
+			return err
+		}
+		onPacket()
+	}
+}
+
+func (c *conn) write(header byte, body []byte) error {
+	packet := append([]byte{header}, appendLength(nil, len(body))...)
+	packet = append(packet, body...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.nc.Write(packet); err != nil {
+		return fmt.Errorf("mqtt: write: %w", err)
+	}
+	return nil
+}
+
+// read は 1 つのパケットを読み、種類と可変ヘッダ以降を返す。
+func (c *conn) read() (byte, []byte, error) {
+	header, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, err := readLength(c.r)
+	if err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+// appendString は 2 バイトの長さを前に付けた UTF-8 文字列を追加する。
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// appendLength は残りの長さを可変長（7 ビットずつ、最上位ビットが継続）で追加する。
+func appendLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+func readLength(r io.ByteReader) (int, error) {
+	n, shift := 0, 0
+	for i := 0; i < 4; i++ {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n |= int(digit&0x7f) << shift
+		if digit&0x80 == 0 {
+			return n, nil
+		}
+		shift += 7
+	}
+	return 0, fmt.Errorf("mqtt: malformed remaining length")
+}
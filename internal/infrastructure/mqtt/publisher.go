@@ -0,0 +1,380 @@
+// Package mqtt は在席・スクワットの状態・今日の回数・最後の rep を MQTT ブローカーに retained で送る。
+// Home Assistant の MQTT Discovery の設定も送るので、ブローカーを登録してあればデバイスとして自動で現れる。
+// 切断されたら間隔を倍にしながらつなぎ直し、つながるたびにすべての値を送り直す。
+package mqtt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+)
+
+const (
+	keepAlive    = 30 * time.Second
+	minReconnect = time.Second
+	maxReconnect = time.Minute
+	tickInterval = time.Second // 不在の判定・日付の変わり目・キープアライブを確かめる間隔
+
+	// DefaultPresenceTimeout はこれだけ顔が見えなければ不在とみなす時間。
+	DefaultPresenceTimeout = 15 * time.Second
+)
+
+// 状態を送るトピック（<TopicPrefix>/<key>）。
+const (
+	topicAvailability = "availability" // online / offline（切断時は Will で offline）
+	topicPresence     = "presence"     // ON / OFF
+	topicState        = "state"        // entity.DetectState の文字列
+	topicTodayCount   = "today_count"  // 今日の rep 数
+	topicLastRep      = "last_rep"     // 最後の rep の時刻（RFC 3339）
+)
+
+// Publisher は MQTT に値を送る。Apply で設定を反映するまではつながない。
+// FaceSeen などは判定のたびに呼んでよく、値が変わったときだけ送る。
+type Publisher struct {
+	GetStatsInputPort usecase.GetStatsInputPort
+	PresenceTimeout   time.Duration
+
+	applyMu sync.Mutex // Apply と Close を直列にする
+	conf    entity.MQTTSetting
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu         sync.Mutex
+	lastSeen   time.Time // 最後に顔が見えた時刻（キャプチャを止めたらゼロ）
+	state      entity.DetectState
+	lastRep    time.Time
+	countStale bool // rep を数えたので今日の回数を読み直す
+	count      int
+	countDate  civil.Date
+	changed    chan struct{}
+}
+
+// Apply は設定を反映する。有効ならブローカーにつなぎ（設定が変わったらつなぎ直す）、無効なら切断する。
+// つなぐのは裏で行い、ブローカーに届かなくてもエラーにはせずにつなぎ直し続ける。
+func (p *Publisher) Apply(conf entity.MQTTSetting) error {
+	if err := conf.Validate(); err != nil {
+		return fmt.Errorf("mqtt: %w", err)
+	}
+	p.applyMu.Lock()
+	defer p.applyMu.Unlock()
+	if conf.Enabled && p.cancel != nil && conf == p.conf {
+		return nil
+	}
+	p.stopLocked()
+	if !conf.Enabled {
+		return nil
+	}
+	p.mu.Lock()
+	if p.changed == nil {
+		p.changed = make(chan struct{}, 1)
+	}
+	p.countStale = true
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.conf, p.cancel, p.done = conf, cancel, make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		p.run(ctx, conf)
+	}(p.done)
+	return nil
+}
+
+// Close は offline を送ってから切断する。アプリの終了時に呼ぶ。
+func (p *Publisher) Close() {
+	p.applyMu.Lock()
+	defer p.applyMu.Unlock()
+	p.stopLocked()
+}
+
+func (p *Publisher) stopLocked() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+	p.cancel, p.done = nil, nil
+}
+
+// FaceSeen は顔が見えた判定のたびに呼ぶ。在席と状態を更新する。
+func (p *Publisher) FaceSeen(at time.Time, state entity.DetectState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	wasPresent := p.presentLocked(time.Now())
+	p.lastSeen = at
+	if !wasPresent || state != p.state {
+		p.state = state
+		p.notifyLocked()
+	}
+}
+
+// RepCompleted は rep を数えたときに呼ぶ。今日の回数と最後の rep の時刻を送る。
+func (p *Publisher) RepCompleted(at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastRep = at
+	p.countStale = true
+	p.notifyLocked()
+}
+
+// CaptureStopped はキャプチャを止めたときに呼ぶ。タイムアウトを待たずに不在にする。
+func (p *Publisher) CaptureStopped() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastSeen = time.Time{}
+	p.state = entity.DetectStateUnknown
+	p.notifyLocked()
+}
+
+func (p *Publisher) notifyLocked() {
+	if p.changed == nil {
+		return
+	}
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Publisher) presentLocked(now time.Time) bool {
+	timeout := p.PresenceTimeout
+	if timeout <= 0 {
+		timeout = DefaultPresenceTimeout
+	}
+	return !p.lastSeen.IsZero() && now.Sub(p.lastSeen) < timeout
+}
+
+// run は ctx が終わるまで、つないでは値を送り、切れたらつなぎ直す。
+func (p *Publisher) run(ctx context.Context, conf entity.MQTTSetting) {
+	clientID, err := newClientID(conf)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	opts := connectOptions{
+		ClientID:  clientID,
+		Username:  conf.Username,
+		Password:  conf.Password,
+		KeepAlive: keepAlive,
+		Will:      &message{Topic: topic(conf, topicAvailability), Payload: "offline", Retain: true},
+	}
+	wait := minReconnect
+	for {
+		c, err := dial(ctx, conf.Broker, opts)
+		if err == nil {
+			log.Printf("mqtt: connected to %s", conf.Broker)
+			wait = minReconnect
+			err = p.serve(ctx, c, conf)
+			if ctx.Err() != nil {
+				// 切断の理由が分かるよう offline を残してから DISCONNECT する（DISCONNECT では Will は送られない）
+				c.publish(message{Topic: topic(conf, topicAvailability), Payload: "offline", Retain: true})
+				c.close()
+				log.Printf("mqtt: disconnected from %s", conf.Broker)
+				return
+			}
+			c.nc.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("%v (reconnecting in %s)", err, wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxReconnect)
+	}
+}
+
+// serve は 1 本の接続で Discovery と値を送り、切れるか ctx が終わるまで変化を送り続ける。
+func (p *Publisher) serve(ctx context.Context, c *conn, conf entity.MQTTSetting) error {
+	var lastRecv atomic.Int64
+	lastRecv.Store(time.Now().UnixNano())
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- c.readLoop(func() { lastRecv.Store(time.Now().UnixNano()) })
+	}()
+
+	for _, m := range discovery(conf) {
+		if err := c.publish(m); err != nil {
+			return err
+		}
+	}
+	if err := c.publish(message{Topic: topic(conf, topicAvailability), Payload: "online", Retain: true}); err != nil {
+		return err
+	}
+	sent := make(map[string]string)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	lastPing := time.Now()
+	for {
+		if err := p.flush(ctx, c, conf, sent); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return fmt.Errorf("mqtt: connection lost: %w", err)
+		case <-p.changed:
+		case now := <-ticker.C:
+			if now.Sub(time.Unix(0, lastRecv.Load())) > keepAlive {
+				return fmt.Errorf("mqtt: no response from broker for %s", keepAlive)
+			}
+			if now.Sub(lastPing) >= keepAlive/2 {
+				if err := c.ping(); err != nil {
+					return err
+				}
+				lastPing = now
+			}
+		}
+	}
+}
+
+// flush はいまの値のうち、この接続でまだ送っていないものを送る。
+func (p *Publisher) flush(ctx context.Context, c *conn, conf entity.MQTTSetting, sent map[string]string) error {
+	values, err := p.values(ctx)
+	if err != nil {
+		// 回数が読めなくても在席・状態は送る
+		log.Println(err)
+	}
+	for key, payload := range values {
+		t := topic(conf, key)
+		if prev, ok := sent[t]; ok && prev == payload {
+			continue
+		}
+		if err := c.publish(message{Topic: t, Payload: payload, Retain: true}); err != nil {
+			return err
+		}
+		sent[t] = payload
+	}
+	return nil
+}
+
+// values はトピックごとのいまの値。今日の回数は rep を数えたときと日付が変わったときだけ読み直す。
+func (p *Publisher) values(ctx context.Context) (map[string]string, error) {
+	now := time.Now()
+	today := civil.DateOf(now)
+	p.mu.Lock()
+	stale := p.countStale || p.countDate != today
+	p.countStale = false
+	p.mu.Unlock()
+
+	var err error
+	if stale {
+		var out *usecase.GetStatsOutput
+		if out, err = p.GetStatsInputPort.Execute(ctx, now); err == nil {
+			p.mu.Lock()
+			p.count, p.countDate = out.RepCount, today
+			p.mu.Unlock()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	presence := "OFF"
+	if p.presentLocked(now) {
+		presence = "ON"
+	}
+	values := map[string]string{
+		topicPresence: presence,
+		topicState:    p.state.String(),
+	}
+	if p.countDate == today {
+		values[topicTodayCount] = strconv.Itoa(p.count)
+	}
+	if !p.lastRep.IsZero() {
+		values[topicLastRep] = p.lastRep.Format(time.RFC3339)
+	}
+	return values, err
+}
+
+func topic(conf entity.MQTTSetting, key string) string {
+	return conf.TopicPrefix + "/" + key
+}
+
+var nonIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// nodeID はトピックの接頭辞から作る Home Assistant のデバイスの ID。
+func nodeID(conf entity.MQTTSetting) string {
+	return nonIDChars.ReplaceAllString(conf.TopicPrefix, "_")
+}
+
+// newClientID は同じブローカーに複数台をつないでも衝突しないよう、接頭辞に乱数を付けた ID を返す。
+func newClientID(conf entity.MQTTSetting) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("mqtt: generate client id: %w", err)
+	}
+	// MQTT 3.1.1 のブローカーが必ず受け付けるのは 23 バイトまでなので、接頭辞を詰める
+	node := nodeID(conf)
+	if len(node) > 14 {
+		node = node[:14]
+	}
+	return node + "-" + hex.EncodeToString(b), nil
+}
+
+// discovery は Home Assistant の MQTT Discovery の設定（retained）を返す。
+func discovery(conf entity.MQTTSetting) []message {
+	if conf.DiscoveryPrefix == "" {
+		return nil
+	}
+	node := nodeID(conf)
+	device := map[string]any{
+		"identifiers": []string{node},
+		"name":        "Desk Squat Tracker",
+		"model":       "desk-squat-tracker",
+	}
+	var states []string
+	for s := entity.DetectStateUnknown; s <= entity.DetectStateGoingUp; s++ {
+		states = append(states, s.String())
+	}
+	entities := []struct {
+		component string
+		key       string
+		config    map[string]any
+	}{
+		{"binary_sensor", topicPresence, map[string]any{
+			"name": "Presence", "device_class": "occupancy", "payload_on": "ON", "payload_off": "OFF",
+		}},
+		{"sensor", topicState, map[string]any{
+			"name": "Squat state", "device_class": "enum", "options": states, "icon": "mdi:human-handsdown",
+		}},
+		{"sensor", topicTodayCount, map[string]any{
+			"name": "Reps today", "state_class": "total_increasing", "unit_of_measurement": "reps", "icon": "mdi:counter",
+		}},
+		{"sensor", topicLastRep, map[string]any{
+			"name": "Last rep", "device_class": "timestamp",
+		}},
+	}
+	messages := make([]message, 0, len(entities))
+	for _, e := range entities {
+		e.config["unique_id"] = node + "_" + e.key
+		e.config["state_topic"] = topic(conf, e.key)
+		e.config["availability_topic"] = topic(conf, topicAvailability)
+		e.config["device"] = device
+		payload, err := json.Marshal(e.config)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, message{
+			Topic:   fmt.Sprintf("%s/%s/%s/%s/config", conf.DiscoveryPrefix, e.component, node, e.key),
+			Payload: string(payload),
+			Retain:  true,
+		})
+	}
+	return messages
+}
@@ -0,0 +1,230 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+)
+
+// stubPacket はスタブのブローカーが受け取ったパケット。
+type stubPacket struct {
+	Kind      byte
+	Username  string
+	Password  string
+	WillTopic string
+	Topic     string
+	Payload   string
+	Retain    bool
+}
+
+// startBrokerStub は 1 本の接続だけを受け付ける最小限の MQTT 3.1.1 ブローカーを net.Listener で立てる。
+// CONNECT には CONNACK（受け付け）、PINGREQ には PINGRESP を返し、受け取ったパケットを順に流す。
+func startBrokerStub(t *testing.T) (string, <-chan stubPacket) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	packets := make(chan stubPacket, 100)
+	go func() {
+		defer close(packets)
+		nc, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer nc.Close()
+		r := bufio.NewReader(nc)
+		for {
+			header, body, err := readStubPacket(r)
+			if err != nil {
+				return
+			}
+			p := stubPacket{Kind: header >> 4}
+			switch p.Kind {
+			case packetConnect:
+				parseStubConnect(body, &p)
+				_, _ = nc.Write([]byte{packetConnAck << 4, 2, 0, 0})
+			case packetPublish:
+				p.Retain = header&0x01 != 0
+				n := int(binary.BigEndian.Uint16(body))
+				p.Topic, p.Payload = string(body[2:2+n]), string(body[2+n:])
+			case packetPingReq:
+				_, _ = nc.Write([]byte{packetPingResp << 4, 0})
+			}
+			packets <- p
+			if p.Kind == packetDisconnect {
+				return
+			}
+		}
+	}()
+	return "tcp://" + ln.Addr().String(), packets
+}
+
+func readStubPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+func parseStubConnect(body []byte, p *stubPacket) {
+	next := func() string {
+		n := int(binary.BigEndian.Uint16(body))
+		s := string(body[2 : 2+n])
+		body = body[2+n:]
+		return s
+	}
+	next() // プロトコル名
+	flags := body[1]
+	body = body[4:] // レベル・フラグ・キープアライブ
+	next()          // クライアント ID
+	if flags&0x04 != 0 {
+		p.WillTopic = next()
+		next()
+	}
+	if flags&0x80 != 0 {
+		p.Username = next()
+	}
+	if flags&0x40 != 0 {
+		p.Password = next()
+	}
+}
+
+// fakeStats は今日の回数を返す GetStatsInputPort。
+type fakeStats struct {
+	count atomic.Int64
+}
+
+func (f *fakeStats) Execute(ctx context.Context, t time.Time) (*usecase.GetStatsOutput, error) {
+	return &usecase.GetStatsOutput{RepCount: int(f.count.Load())}, nil
+}
+
+// retainedWatcher は受け取った PUBLISH から、トピックごとの最新の値を覚える。
+type retainedWatcher struct {
+	t         *testing.T
+	packets   <-chan stubPacket
+	retained  map[string]string
+	discovery int
+}
+
+// waitFor は topic に payload が届くまで読み進める。
+func (w *retainedWatcher) waitFor(topic, payload string) {
+	w.t.Helper()
+	deadline := time.After(5 * time.Second)
+	for w.retained[topic] != payload {
+		select {
+		case p, ok := <-w.packets:
+			if !ok {
+				w.t.Fatalf("broker connection closed while waiting for %s=%q (have %q)", topic, payload, w.retained[topic])
+			}
+			if p.Kind != packetPublish {
+				continue
+			}
+			if !p.Retain {
+				w.t.Errorf("%s was published without retain", p.Topic)
+			}
+			if strings.HasPrefix(p.Topic, "homeassistant/") {
+				w.discovery++
+			}
+			w.retained[p.Topic] = p.Payload
+		case <-deadline:
+			w.t.Fatalf("timed out waiting for %s=%q (have %q)", topic, payload, w.retained[topic])
+		}
+	}
+}
+
+func TestPublisher_PublishesStateToBroker(t *testing.T) {
+	broker, packets := startBrokerStub(t)
+	stats := &fakeStats{}
+	stats.count.Store(3)
+	p := &Publisher{GetStatsInputPort: stats}
+	t.Cleanup(p.Close)
+
+	conf := entity.MQTTSetting{
+		Enabled:         true,
+		Broker:          broker,
+		Username:        "user",
+		Password:        "secret",
+		TopicPrefix:     "dst",
+		DiscoveryPrefix: "homeassistant",
+	}
+	if err := p.Apply(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case c := <-packets:
+		if c.Kind != packetConnect || c.Username != "user" || c.Password != "secret" || c.WillTopic != "dst/availability" {
+			t.Fatalf("first packet = %+v, want CONNECT with credentials and an availability will", c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("publisher did not connect")
+	}
+
+	w := &retainedWatcher{t: t, packets: packets, retained: make(map[string]string)}
+	w.waitFor("dst/availability", "online")
+	w.waitFor("dst/presence", "OFF")
+	w.waitFor("dst/today_count", "3")
+	if w.discovery != 4 {
+		t.Errorf("received %d discovery configs, want 4", w.discovery)
+	}
+
+	p.FaceSeen(time.Now(), entity.DetectStateStanding)
+	w.waitFor("dst/presence", "ON")
+	w.waitFor("dst/state", entity.DetectStateStanding.String())
+
+	at := time.Now()
+	stats.count.Store(4)
+	p.RepCompleted(at)
+	w.waitFor("dst/today_count", "4")
+	w.waitFor("dst/last_rep", at.Format(time.RFC3339))
+
+	p.CaptureStopped()
+	w.waitFor("dst/presence", "OFF")
+
+	// 終了時は offline を残してから DISCONNECT する
+	p.Close()
+	w.waitFor("dst/availability", "offline")
+	select {
+	case d := <-packets:
+		if d.Kind != packetDisconnect {
+			t.Errorf("packet after offline = %+v, want DISCONNECT", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("publisher did not send DISCONNECT")
+	}
+}
+
+func TestPublisher_ApplyRejectsPasswordWithoutUsername(t *testing.T) {
+	p := &Publisher{GetStatsInputPort: &fakeStats{}}
+	err := p.Apply(entity.MQTTSetting{Enabled: true, Broker: "tcp://127.0.0.1:1883", Password: "secret", TopicPrefix: "dst"})
+	if err == nil {
+		p.Close()
+		t.Fatal("Apply accepted a password without a username")
+	}
+}
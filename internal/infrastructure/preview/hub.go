@@ -0,0 +1,183 @@
+// Package preview はカメラのプレビュー画像をローカルの HTTP エンドポイントで配信する。
+// Wails のイベントで data URL を送る代わりに、見ている画面があるときだけ JPEG を受け取り、
+// 1 枚ずつのロングポーリング（/camera/preview.jpg）か MJPEG（/camera/preview.mjpeg）で返す。
+package preview
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	JPEGPath  = "/camera/preview.jpg"
+	MJPEGPath = "/camera/preview.mjpeg"
+
+	tokenHeader   = "X-Preview-Token"
+	seqHeader     = "X-Preview-Seq"
+	pollTimeout   = 2 * time.Second // 新しいフレームが来なければ 204 を返す
+	viewerTimeout = 3 * time.Second // 最後のポーリングからこの間は見ている画面があるとみなす
+	mjpegBoundary = "preview-frame"
+)
+
+// Hub は最新のプレビュー画像を保持し、見ている画面（ポーリング・MJPEG の接続）に配る。
+type Hub struct {
+	token  string
+	hidden atomic.Bool
+
+	mu       sync.Mutex
+	frame    []byte
+	seq      uint64
+	changed  chan struct{} // 次のフレームが来たら閉じる
+	viewers  int           // 待機中のポーリングと MJPEG の接続数
+	lastPoll time.Time
+}
+
+// NewHub はランダムなトークンで認証する Hub を返す。
+func NewHub() (*Hub, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("preview: generate token: %w", err)
+	}
+	return &Hub{token: hex.EncodeToString(b), changed: make(chan struct{})}, nil
+}
+
+// URL は 1 枚ずつ取得するエンドポイントの URL（トークン付き）を返す。
+// after=<X-Preview-Seq の値> を付けると、それより新しいフレームが来るまで待つ。
+func (h *Hub) URL() string {
+	return JPEGPath + "?token=" + h.token
+}
+
+// SetHidden はウィンドウが隠れているかを設定する。隠れている間は Wanted が false になる。
+func (h *Hub) SetHidden(hidden bool) {
+	h.hidden.Store(hidden)
+}
+
+// Wanted はプレビューを見ている画面があるかを返す。false の間はエンコード自体を省いてよい。
+func (h *Hub) Wanted() bool {
+	if h.hidden.Load() {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.viewers > 0 || time.Since(h.lastPoll) < viewerTimeout
+}
+
+// Publish は新しいプレビュー画像を配る。jpeg はコピーするので、呼び出し側は使い回してよい。
+func (h *Hub) Publish(jpeg []byte) {
+	frame := append([]byte(nil), jpeg...)
+	h.mu.Lock()
+	h.frame = frame
+	h.seq++
+	close(h.changed)
+	h.changed = make(chan struct{})
+	h.mu.Unlock()
+}
+
+// Middleware はプレビューのパスだけを処理し、それ以外は next（アセット）に渡す。
+func (h *Hub) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case JPEGPath, MJPEGPath:
+			h.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := r.Header.Get(tokenHeader)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Path == MJPEGPath {
+		h.serveMJPEG(w, r)
+		return
+	}
+	after, _ := strconv.ParseUint(r.URL.Query().Get("after"), 10, 64)
+	frame, seq, ok := h.next(r, after, pollTimeout)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set(seqHeader, strconv.FormatUint(seq, 10))
+	_, _ = w.Write(frame)
+}
+
+// serveMJPEG は接続が切れるまで新しいフレームを multipart/x-mixed-replace で送り続ける。
+func (h *Hub) serveMJPEG(w http.ResponseWriter, r *http.Request) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mjpegBoundary)
+	var seq uint64
+	for {
+		frame, next, ok := h.next(r, seq, pollTimeout)
+		if r.Context().Err() != nil {
+			return
+		}
+		if !ok {
+			continue
+		}
+		seq = next
+		if _, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", mjpegBoundary, len(frame)); err != nil {
+			return
+		}
+		if _, err := w.Write(frame); err != nil {
+			return
+		}
+		if _, err := w.Write([]byte("\r\n")); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// next は after より新しいフレームを待って返す。timeout までに来なければ ok は false。
+// 待っている間は見ている画面として数える。
+func (h *Hub) next(r *http.Request, after uint64, timeout time.Duration) ([]byte, uint64, bool) {
+	h.mu.Lock()
+	h.lastPoll = time.Now()
+	if h.seq > after && h.frame != nil {
+		frame, seq := h.frame, h.seq
+		h.mu.Unlock()
+		return frame, seq, true
+	}
+	h.viewers++
+	changed := h.changed
+	h.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-changed:
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.viewers--
+	h.lastPoll = time.Now()
+	if h.seq > after && h.frame != nil {
+		return h.frame, h.seq, true
+	}
+	return nil, 0, false
+}
@@ -1,14 +1,20 @@
 package python
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,40 +28,84 @@ import (
 const healthRetries = 10
 const healthRetryDelay = 3 * time.Second
 
+// ヘルパーとの /health ハンドシェイクで扱うプロトコルバージョンの範囲。
+// /detect のリクエスト・レスポンスに互換性のない変更を入れたら両側で上げる。
+const (
+	faceMinProtocolVersion = 1
+	faceMaxProtocolVersion = 1
+)
+
+// listeningPrefix はヘルパーが listen 開始時に stdout へ書く行の接頭辞（"LISTENING host:port"）。
+const listeningPrefix = "LISTENING "
+
 var (
 	faceChildMu sync.Mutex
 	faceChild   *exec.Cmd
+	// faceChildDir は検証済みのヘルパーをコピーした一時ディレクトリ。子プロセス終了後に消す。
+	faceChildDir string
 )
 
+// faceHandshake は GET /health のレスポンス。
+type faceHandshake struct {
+	OK              bool     `json:"ok"`
+	ProtocolVersion int      `json:"protocol_version"`
+	Model           string   `json:"model"`
+	Endpoints       []string `json:"endpoints"`
+	Options         []string `json:"options"`
+}
+
+// validate は Go 側と互換性のあるヘルパーかを確認する。
+func (h *faceHandshake) validate() error {
+	if h.ProtocolVersion < faceMinProtocolVersion || h.ProtocolVersion > faceMaxProtocolVersion {
+		return xerrors.Errorf("face_mediapipe: helper speaks protocol version %d (model %q), but this app supports %d..%d; update the face_detect helper to match the app",
+			h.ProtocolVersion, h.Model, faceMinProtocolVersion, faceMaxProtocolVersion)
+	}
+	if !slices.Contains(h.Endpoints, "/detect") {
+		return xerrors.Errorf("face_mediapipe: helper (model %q) does not advertise the /detect endpoint (endpoints: %v)", h.Model, h.Endpoints)
+	}
+	return nil
+}
+
 func StopFaceDetectServer() {
 	faceChildMu.Lock()
-	cmd := faceChild
-	faceChild = nil
+	cmd, dir := faceChild, faceChildDir
+	faceChild, faceChildDir = nil, ""
 	faceChildMu.Unlock()
 	if cmd == nil || cmd.Process == nil {
 		return
 	}
 	faceChildKill(cmd)
 	_ = cmd.Wait()
+	_ = os.RemoveAll(dir)
+	config.SetFaceDetectServerAddr("")
 }
 
 func StartFaceDetectServer(ctx context.Context) (err error) {
 	cfg := config.Get().FaceDetectServer
 	if !cfg.Debug {
-		return waitFaceDetectHealth(cfg.ServerURL() + "/health")
+		if cfg.ServerPort == 0 {
+			return xerrors.New("face_mediapipe: ServerPort is required when the server is not started by the app")
+		}
+		return waitFaceDetectHandshake(cfg.ServerURL() + "/health")
 	}
 
-	cmd, err := buildFaceDetectCmd(ctx, cfg)
+	cmd, dir, err := buildFaceDetectCmd(ctx, cfg)
 	if err != nil {
 		return err
 	}
-	cmd.Stdout = os.Stdout
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return xerrors.Errorf("face_mediapipe: stdout pipe: %w", err)
+	}
 	cmd.Stderr = os.Stderr
 	if err := cmd.Start(); err != nil {
+		_ = os.RemoveAll(dir)
 		return xerrors.Errorf("face_mediapipe: start: %w", err)
 	}
 	faceChildMu.Lock()
 	faceChild = cmd
+	faceChildDir = dir
 	faceChildMu.Unlock()
 	defer func() {
 		if err != nil {
@@ -63,28 +113,107 @@ func StartFaceDetectServer(ctx context.Context) (err error) {
 		}
 	}()
 
-	err = waitFaceDetectHealth(cfg.ServerURL() + "/health")
+	addr, err := waitFaceDetectListening(ctx, stdout, os.Stdout, healthRetries*healthRetryDelay)
+	if err != nil {
+		return err
+	}
+	config.SetFaceDetectServerAddr(addr)
+
+	err = waitFaceDetectHandshake(config.Get().FaceDetectServer.ServerURL() + "/health")
 	return err
 }
 
-func buildFaceDetectCmd(ctx context.Context, cfg config.FaceDetectServer) (*exec.Cmd, error) {
-	var cmd *exec.Cmd
-	if p := resolveBundledFaceDetect(); p != "" {
-		cmd = exec.CommandContext(ctx, p, "--serve", "--port", strconv.Itoa(cfg.ServerPort))
+// buildFaceDetectCmd はヘルパーの起動コマンドを組み立てる。同梱・指定のヘルパーは検証済みのコピーを起動し、
+// そのコピーを置いた一時ディレクトリを dir で返す（uv 経由や検証省略時は空）。
+func buildFaceDetectCmd(ctx context.Context, cfg config.FaceDetectServer) (cmd *exec.Cmd, dir string, err error) {
+	// ServerPort が 0 の場合はそのまま渡し、ヘルパー側で空きポートを bind させる。
+	serveArgs := []string{"--serve", "--host", cfg.ServerHost, "--port", strconv.Itoa(cfg.ServerPort)}
+	p := cfg.HelperPath
+	skippedBundled := ""
+	if p == "" {
+		p = resolveBundledFaceDetect()
+		// build_face_detect.sh を通さずにビルドするとマニフェストが空になる。検証できない同梱ヘルパーは使わず、
+		// helper/ のスクリプトを uv で起動する開発用の経路に切り替える。
+		if p != "" && !cfg.AllowUnverifiedHelper && len(expectedFaceDetectHashes(faceDetectPlatform())) == 0 {
+			log.Printf("face_mediapipe: no face_detect hash for %s is embedded in this build; ignoring %s and falling back to uv run (run task build:python:binary before go build to use it)", faceDetectPlatform(), p)
+			skippedBundled, p = p, ""
+		}
+	}
+	if p != "" {
+		exe := p
+		if cfg.AllowUnverifiedHelper {
+			log.Printf("face_mediapipe: skipping integrity check for %s (AllowUnverifiedHelper)", p)
+		} else if exe, dir, err = stageFaceDetectExecutable(p); err != nil {
+			return nil, "", err
+		}
+		cmd = exec.CommandContext(ctx, exe, serveArgs...)
 		cmd.Dir = filepath.Dir(p)
 	} else {
 		helperDir, err := resolveHelperDirNextToBin()
 		if err != nil {
-			return nil, xerrors.Errorf("face_mediapipe: helper dir: %w", err)
+			if skippedBundled != "" {
+				return nil, "", errors.ErrIntegrity.Errorf("face_mediapipe: no face_detect hash for %s is embedded in this build and no helper scripts were found; refusing to run %s", faceDetectPlatform(), skippedBundled)
+			}
+			return nil, "", xerrors.Errorf("face_mediapipe: helper dir: %w", err)
 		}
-		cmd = exec.CommandContext(ctx, "uv", "run", cfg.ScriptName, "--serve", "--port", strconv.Itoa(cfg.ServerPort))
+		cmd = exec.CommandContext(ctx, "uv", append([]string{"run", cfg.ScriptName}, serveArgs...)...)
 		cmd.Dir = helperDir
 	}
 	faceChildPrepare(cmd)
-	return cmd, nil
+	return cmd, dir, nil
+}
+
+// waitFaceDetectListening はヘルパーの stdout から "LISTENING host:port" 行を待ち、そのアドレスを返す。
+// それ以外の行と、通知後の出力はそのまま out へ流す。
+func waitFaceDetectListening(ctx context.Context, stdout io.Reader, out io.Writer, timeout time.Duration) (string, error) {
+	found := make(chan string, 1)
+	go func() {
+		// Scanner は先読みしたデータを内部バッファに持つため、通知後も同じ Scanner で読み続けて流す
+		// （stdout を直接 io.Copy すると、LISTENING 行と一緒に読まれた後続の出力が失われる）。
+		notified := false
+		sc := bufio.NewScanner(stdout)
+		for sc.Scan() {
+			line := sc.Text()
+			if !notified {
+				if addr, ok := strings.CutPrefix(line, listeningPrefix); ok {
+					found <- strings.TrimSpace(addr)
+					close(found)
+					notified = true
+					continue
+				}
+			}
+			_, _ = io.WriteString(out, line+"\n")
+		}
+		// 長すぎる行などで Scanner が止まっても、ヘルパーが書き込みで詰まらないよう残りを流し続ける
+		if sc.Err() != nil {
+			_, _ = io.Copy(out, stdout)
+		}
+		if !notified {
+			close(found)
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case addr, ok := <-found:
+		if !ok {
+			return "", xerrors.New("face_mediapipe: helper exited before reporting its port")
+		}
+		if _, port, err := net.SplitHostPort(addr); err != nil || port == "0" {
+			return "", xerrors.Errorf("face_mediapipe: invalid listening address %q", addr)
+		}
+		return addr, nil
+	case <-timer.C:
+		return "", xerrors.Errorf("face_mediapipe: helper did not report its port within %s", timeout)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
 }
 
-func waitFaceDetectHealth(healthURL string) error {
+// waitFaceDetectHandshake は /health が応答するまで待ち、ハンドシェイクを検証して記録する。
+// 非互換なヘルパーはリトライせずにエラーにする。
+func waitFaceDetectHandshake(healthURL string) error {
 	for i := 0; i < healthRetries; i++ {
 		resp, err := http.Get(healthURL)
 		if err != nil {
@@ -94,14 +223,24 @@ func waitFaceDetectHealth(healthURL string) error {
 			time.Sleep(healthRetryDelay)
 			continue
 		}
-		_ = resp.Body.Close()
 		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
 			if i == healthRetries-1 {
 				return xerrors.New("face_mediapipe: health check failed")
 			}
 			time.Sleep(healthRetryDelay)
 			continue
 		}
+		var hs faceHandshake
+		err = json.NewDecoder(resp.Body).Decode(&hs)
+		_ = resp.Body.Close()
+		if err != nil {
+			return xerrors.Errorf("face_mediapipe: decode handshake: %w", err)
+		}
+		if err := hs.validate(); err != nil {
+			return err
+		}
+		log.Printf("face_mediapipe: helper protocol=%d model=%s options=%v", hs.ProtocolVersion, hs.Model, hs.Options)
 		return nil
 	}
 	return xerrors.New("face_mediapipe: health check failed")
@@ -145,6 +284,7 @@ func NewMediaPipeFaceRepository() (repository.FaceRepository, error) {
 	}, nil
 }
 
+// mediaPipeResult は /detect のレスポンス。先頭の顔はヘルパーが選んだスコア最大の顔で、判定にはこれを使う。
 type mediaPipeResult struct {
 	X           int `json:"x"`
 	Y           int `json:"y"`
@@ -155,8 +295,8 @@ type mediaPipeResult struct {
 }
 
 func (r *MediaPipeFaceRepository) Detect(ctx context.Context, frame []byte, t time.Time) (*entity.Face, error) {
-	url := config.Get().FaceDetectServer.ServerURL() + "/detect"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(frame))
+	detectURL := config.Get().FaceDetectServer.ServerURL() + "/detect"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, detectURL, bytes.NewReader(frame))
 	if err != nil {
 		return nil, xerrors.Errorf("face_mediapipe: new request: %w", err)
 	}
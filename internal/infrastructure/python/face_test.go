@@ -0,0 +1,46 @@
+package python
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitFaceDetectListening_KeepsOutputAfterNotification(t *testing.T) {
+	// LISTENING 行と後続の出力が 1 回の Read で届いても、後続の行を失わない
+	stdout := strings.NewReader("loading model\nLISTENING 127.0.0.1:8765\nready\nserving\n")
+	pr, pw := io.Pipe()
+	want := "loading model\nready\nserving\n"
+	got := make(chan string, 1)
+	go func() {
+		buf := make([]byte, len(want))
+		n, _ := io.ReadFull(pr, buf)
+		got <- string(buf[:n])
+	}()
+
+	addr, err := waitFaceDetectListening(context.Background(), stdout, pw, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != "127.0.0.1:8765" {
+		t.Errorf("addr = %q, want 127.0.0.1:8765", addr)
+	}
+	// 通知後の行は非同期に流れる
+	select {
+	case out := <-got:
+		if out != want {
+			t.Errorf("forwarded output = %q, want %q", out, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output after LISTENING was not forwarded")
+	}
+}
+
+func TestWaitFaceDetectListening_ExitBeforeNotification(t *testing.T) {
+	_, err := waitFaceDetectListening(context.Background(), strings.NewReader("crashed\n"), io.Discard, time.Second)
+	if err == nil {
+		t.Fatal("expected an error when the helper exits without reporting its port")
+	}
+}
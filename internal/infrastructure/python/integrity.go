@@ -0,0 +1,108 @@
+package python
+
+import (
+	"bufio"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+	"golang.org/x/xerrors"
+)
+
+// faceDetectManifest はビルド時に埋め込まれる face_detect の期待ハッシュ一覧（"<sha256>  <os>/<arch>" 形式）。
+//
+//go:embed face_detect.sha256
+var faceDetectManifest string
+
+// faceDetectPlatform はマニフェストの <os>/<arch> 列と比較する、このビルドのプラットフォーム。
+func faceDetectPlatform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// normalizeFaceDetectPlatform は Python の platform.machine() 由来の表記を GOARCH に揃える。
+func normalizeFaceDetectPlatform(p string) string {
+	goos, arch, ok := strings.Cut(strings.ToLower(p), "/")
+	if !ok {
+		return ""
+	}
+	switch arch {
+	case "x86_64":
+		arch = "amd64"
+	case "aarch64":
+		arch = "arm64"
+	}
+	return goos + "/" + arch
+}
+
+// expectedFaceDetectHashes はマニフェストから platform 向けの SHA-256（小文字 hex）の集合を取り出す。
+// <os>/<arch> 列の無い行や他プラットフォーム向けの行は使わない。
+func expectedFaceDetectHashes(platform string) map[string]bool {
+	hashes := make(map[string]bool)
+	sc := bufio.NewScanner(strings.NewReader(faceDetectManifest))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields[0]) != sha256.Size*2 {
+			continue
+		}
+		if normalizeFaceDetectPlatform(fields[1]) != platform {
+			continue
+		}
+		hashes[strings.ToLower(fields[0])] = true
+	}
+	return hashes
+}
+
+// stageFaceDetectExecutable は path を 0700 の一時ディレクトリへコピーしながらハッシュを取り、
+// マニフェストと一致したときだけコピー先を返す。検証後に元ファイルが差し替えられても、
+// 起動されるのは検証済みのコピーになる。dir は子プロセス終了後に os.RemoveAll で消す。
+// 不一致・このプラットフォーム向けのハッシュが無いときは errors.ErrIntegrity を返す。
+func stageFaceDetectExecutable(path string) (staged, dir string, err error) {
+	platform := faceDetectPlatform()
+	expected := expectedFaceDetectHashes(platform)
+	if len(expected) == 0 {
+		return "", "", errors.ErrIntegrity.Errorf("face_mediapipe: no face_detect hash for %s is embedded in this build; refusing to run %s", platform, path)
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return "", "", xerrors.Errorf("face_mediapipe: open helper: %w", err)
+	}
+	defer src.Close()
+
+	dir, err = os.MkdirTemp("", "face_detect-*")
+	if err != nil {
+		return "", "", xerrors.Errorf("face_mediapipe: stage helper: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+	staged = filepath.Join(dir, filepath.Base(path))
+	dst, err := os.OpenFile(staged, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0700)
+	if err != nil {
+		return "", "", xerrors.Errorf("face_mediapipe: stage helper: %w", err)
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(dst, h), src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", "", xerrors.Errorf("face_mediapipe: stage helper: %w", err)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if !expected[sum] {
+		return "", "", errors.ErrIntegrity.Errorf("face_mediapipe: %s has unexpected sha256 %s for %s; refusing to run it", path, sum, platform)
+	}
+	return staged, dir, nil
+}
@@ -0,0 +1,112 @@
+package python
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+// writeFakeHelper は検証対象のダミーのヘルパーを書き出し、そのパスと SHA-256 を返す。
+func writeFakeHelper(t *testing.T) (string, string) {
+	t.Helper()
+	content := []byte("#!/bin/sh\necho fake face_detect\n")
+	p := filepath.Join(t.TempDir(), "face_detect")
+	if err := os.WriteFile(p, content, 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	return p, hex.EncodeToString(sum[:])
+}
+
+func setManifest(t *testing.T, manifest string) {
+	t.Helper()
+	orig := faceDetectManifest
+	faceDetectManifest = manifest
+	t.Cleanup(func() { faceDetectManifest = orig })
+}
+
+func TestStageFaceDetectExecutable(t *testing.T) {
+	p, sum := writeFakeHelper(t)
+	setManifest(t, "# comment\n"+sum+"  "+faceDetectPlatform()+"\n")
+
+	staged, dir, err := stageFaceDetectExecutable(p)
+	if err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if filepath.Dir(staged) != dir {
+		t.Errorf("staged %s is not inside %s", staged, dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("dir perm = %o, want 700", perm)
+	}
+	want, _ := os.ReadFile(p)
+	got, err := os.ReadFile(staged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("staged helper differs from the original")
+	}
+
+	// 検証後に元ファイルを差し替えても、起動するコピーは変わらない
+	if err := os.WriteFile(p, []byte("tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(staged); !bytes.Equal(got, want) {
+		t.Error("staged helper changed after the original was replaced")
+	}
+}
+
+func TestStageFaceDetectExecutable_Refuses(t *testing.T) {
+	p, sum := writeFakeHelper(t)
+	other := "plan9/mips"
+	if faceDetectPlatform() == other {
+		other = "plan9/arm"
+	}
+	tests := []struct {
+		name     string
+		manifest string
+	}{
+		{"empty manifest", ""},
+		{"hash for another platform", sum + "  " + other + "\n"},
+		{"no platform column", sum + "\n"},
+		{"hash mismatch", hex.EncodeToString(make([]byte, sha256.Size)) + "  " + faceDetectPlatform() + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setManifest(t, tt.manifest)
+			staged, dir, err := stageFaceDetectExecutable(p)
+			if !errors.Is(err, errors.ErrIntegrity) {
+				t.Fatalf("err = %v, want ErrIntegrity", err)
+			}
+			if staged != "" || dir != "" {
+				t.Errorf("staged = %q, dir = %q; want both empty", staged, dir)
+			}
+		})
+	}
+}
+
+func TestNormalizeFaceDetectPlatform(t *testing.T) {
+	tests := map[string]string{
+		"darwin/arm64":  "darwin/arm64",
+		"Linux/x86_64":  "linux/amd64",
+		"linux/aarch64": "linux/arm64",
+		"darwin":        "",
+	}
+	for in, want := range tests {
+		if got := normalizeFaceDetectPlatform(in); got != want {
+			t.Errorf("normalizeFaceDetectPlatform(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
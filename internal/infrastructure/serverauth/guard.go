@@ -0,0 +1,72 @@
+// Package serverauth はヘッドレス起動（-tags server）で配信する画面とバインディングをトークンで守る。
+// ブラウザで /?token=<token> を開くと HttpOnly の Cookie を発行し、以降はその Cookie か
+// Authorization: Bearer <token> を持つリクエストだけをアセットサーバー（/wails/runtime を含む）に通す。
+//
+// Wails が Assets.Middleware を通さずに処理する /health と /wails/events（イベントの WebSocket）は守れない。
+// イベントには設定やトークンを含めないこと。
+package serverauth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+const cookieName = "dst_server_token"
+
+// Guard はトークンを確かめる Assets.Middleware。
+type Guard struct {
+	token string
+}
+
+func NewGuard(token string) *Guard {
+	return &Guard{token: token}
+}
+
+// Middleware はトークンを持たないリクエストを 401 で拒否する。
+// ?token= が正しければ Cookie を発行し、GET ならトークンを URL から消してリダイレクトする。
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// プレビューの ?token= はプレビュー用のトークンなので、一致したときだけここで使う
+		if query := r.URL.Query(); g.valid(query.Get("token")) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     cookieName,
+				Value:    g.token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+			if r.Method == http.MethodGet {
+				query.Del("token")
+				u := *r.URL
+				u.RawQuery = query.Encode()
+				http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if g.valid(bearer(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if c, err := r.Cookie(cookieName); err == nil && g.valid(c.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, "unauthorized: open the URL with ?token=<token> printed at startup", http.StatusUnauthorized)
+	})
+}
+
+func (g *Guard) valid(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(g.token)) == 1
+}
+
+func bearer(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+		return token
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	dservice "github.com/kikils/desk-squat-tracker/internal/domain/service"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/memory"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+	"golang.org/x/xerrors"
+)
+
+// LoadLabels は正解の rep 完了時刻を読み込む。1 行 1 時刻（RFC3339）、空行と # 始まりの行は無視する。
+func LoadLabels(path string) ([]time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("session: open labels: %w", err)
+	}
+	defer f.Close()
+
+	var labels []time.Time
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return nil, xerrors.Errorf("session: %s:%d: %w", path, line, err)
+		}
+		labels = append(labels, t)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, xerrors.Errorf("session: read labels: %w", err)
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Before(labels[j]) })
+	return labels, nil
+}
+
+// Metrics は判定した rep と正解 rep の突き合わせ結果。
+type Metrics struct {
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+	CountError     int // |判定 rep 数 - 正解 rep 数| の合計
+}
+
+func (m Metrics) Precision() float64 {
+	if m.TruePositives+m.FalsePositives == 0 {
+		return 0
+	}
+	return float64(m.TruePositives) / float64(m.TruePositives+m.FalsePositives)
+}
+
+func (m Metrics) Recall() float64 {
+	if m.TruePositives+m.FalseNegatives == 0 {
+		return 0
+	}
+	return float64(m.TruePositives) / float64(m.TruePositives+m.FalseNegatives)
+}
+
+func (m Metrics) F1() float64 {
+	p, r := m.Precision(), m.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// Add は複数セッションの結果を合算する。
+func (m Metrics) Add(o Metrics) Metrics {
+	return Metrics{
+		TruePositives:  m.TruePositives + o.TruePositives,
+		FalsePositives: m.FalsePositives + o.FalsePositives,
+		FalseNegatives: m.FalseNegatives + o.FalseNegatives,
+		CountError:     m.CountError + o.CountError,
+	}
+}
+
+// MatchReps は判定 rep を時刻の近い正解 rep に 1 対 1 で対応付ける。tolerance より離れたものは対応させない。
+func MatchReps(predicted, labels []time.Time, tolerance time.Duration) Metrics {
+	used := make([]bool, len(labels))
+	m := Metrics{}
+	for _, p := range predicted {
+		best := -1
+		var bestDiff time.Duration
+		for i, l := range labels {
+			if used[i] {
+				continue
+			}
+			diff := p.Sub(l).Abs()
+			if diff <= tolerance && (best < 0 || diff < bestDiff) {
+				best, bestDiff = i, diff
+			}
+		}
+		if best < 0 {
+			m.FalsePositives++
+			continue
+		}
+		used[best] = true
+		m.TruePositives++
+	}
+	m.FalseNegatives = len(labels) - m.TruePositives
+	m.CountError = len(predicted) - len(labels)
+	if m.CountError < 0 {
+		m.CountError = -m.CountError
+	}
+	return m
+}
+
+// Evaluate は記録済みの顔検出結果を setting で判定し直し、正解 rep と比較する。
+// 判定は毎回新しいメモリリポジトリで行うため、他の評価と状態を共有しない。
+func Evaluate(ctx context.Context, s *Session, labels []time.Time, setting *entity.Setting, tolerance time.Duration) (Metrics, error) {
+	faceRepository := NewReplayFaceRepository(s)
+	judgementRepository := memory.NewJudgementRepository()
+	settingRepository := memory.NewSettingRepository()
+	if err := settingRepository.Save(setting); err != nil {
+		return Metrics{}, err
+	}
+	squatJudger := dservice.NewSquatJudger(faceRepository, judgementRepository, settingRepository)
+	inputPort := usecase.NewWatchSquatUsecase(faceRepository, judgementRepository, squatJudger)
+
+	res, err := Replay(ctx, s, inputPort)
+	if err != nil {
+		return Metrics{}, err
+	}
+	var predicted []time.Time
+	for _, j := range res.Judgements {
+		if j.IsRepCompleted {
+			predicted = append(predicted, j.Timestamp)
+		}
+	}
+	return MatchReps(predicted, labels, tolerance), nil
+}
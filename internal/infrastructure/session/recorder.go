@@ -0,0 +1,98 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+	"golang.org/x/xerrors"
+)
+
+// Recorder はキャプチャ中のフレームと判定結果を記録ファイルへ書き出す。
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	enc     *json.Encoder
+	privacy bool
+	path    string
+}
+
+// NewRecorder は dir に session-YYYYMMDD-HHMMSS.jsonl を作成し、ヘッダーを書き込む。
+// privacy が true の場合、JPEG は保存せず顔の検出結果と判定のみを記録する。setting は判定しきい値と取り込みの設定だけを記録する。
+func NewRecorder(dir string, privacy bool, setting *entity.Setting) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, xerrors.Errorf("session: mkdir: %w", err)
+	}
+	now := time.Now()
+	path := filepath.Join(dir, "session-"+now.Format("20060102-150405")+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, xerrors.Errorf("session: create: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	r := &Recorder{file: f, w: w, enc: json.NewEncoder(w), privacy: privacy, path: path}
+	if err := r.enc.Encode(&record{
+		Type:      RecordTypeHeader,
+		Timestamp: now,
+		Version:   formatVersion,
+		Privacy:   privacy,
+		Setting:   recordedSettingFrom(setting),
+	}); err != nil {
+		_ = f.Close()
+		return nil, xerrors.Errorf("session: write header: %w", err)
+	}
+	return r, nil
+}
+
+// Path は記録ファイルのパスを返す。
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// RecordFrame は 1 フレーム分の入力と結果を記録する。out は検出に失敗した場合など nil でもよい。
+func (r *Recorder) RecordFrame(in *usecase.WatchSquatInput, out *usecase.WatchSquatOutput) error {
+	rec := &record{
+		Type:        RecordTypeFrame,
+		Timestamp:   in.Timestamp,
+		Crop:        in.Crop,
+		FrameWidth:  in.FrameWidth,
+		FrameHeight: in.FrameHeight,
+	}
+	if out != nil {
+		rec.Face = out.Face
+		rec.Judgement = out.Judgement
+	}
+	if !r.privacy {
+		rec.JPEG = in.Frame
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return xerrors.New("session: recorder closed")
+	}
+	if err := r.enc.Encode(rec); err != nil {
+		return xerrors.Errorf("session: write frame: %w", err)
+	}
+	return nil
+}
+
+// Close はバッファを書き出してファイルを閉じる。
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.w.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	return err
+}
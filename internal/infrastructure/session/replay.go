@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+	"github.com/kikils/desk-squat-tracker/internal/usecase"
+)
+
+// ReplayFaceRepository は記録された顔検出結果を返す FaceRepository。
+// フレームのタイムスタンプで記録を引くため、検出器なしで決定的に再生できる。
+type ReplayFaceRepository struct {
+	faces map[int64]*entity.Face
+}
+
+func NewReplayFaceRepository(s *Session) repository.FaceRepository {
+	faces := make(map[int64]*entity.Face, len(s.Frames))
+	for _, f := range s.Frames {
+		if f.Face == nil {
+			continue
+		}
+		// 検出器は切り抜いた画像上の座標を返すため、記録した元フレーム上の座標をそちらに合わせる
+		face := f.Face
+		if f.Crop != nil {
+			face = face.ToCrop(*f.Crop)
+		}
+		faces[f.Timestamp.UnixNano()] = face
+	}
+	return &ReplayFaceRepository{faces: faces}
+}
+
+func (r *ReplayFaceRepository) Detect(ctx context.Context, frame []byte, t time.Time) (*entity.Face, error) {
+	face, ok := r.faces[t.UnixNano()]
+	if !ok {
+		return nil, errors.ErrNotFound.Errorf("session: no recorded face at %s", t.Format(time.RFC3339Nano))
+	}
+	copied := *face
+	copied.Timestamp = t
+	return &copied, nil
+}
+
+// ReplayResult は再生結果の集計。
+type ReplayResult struct {
+	Frames       int
+	Faces        int
+	Reps         int // 再生で完了と判定された rep 数
+	RecordedReps int // 記録時に完了と判定された rep 数
+	Mismatches   int // 記録時と状態が異なったフレーム数
+	Judgements   []*entity.Judgement
+}
+
+// Replay は記録された全フレームを記録順に inputPort へ流し、記録時の判定と比較する。
+func Replay(ctx context.Context, s *Session, inputPort usecase.WatchSquatInputPort) (*ReplayResult, error) {
+	res := &ReplayResult{RecordedReps: s.RepCount()}
+	for _, f := range s.Frames {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		out, err := inputPort.Execute(ctx, &usecase.WatchSquatInput{
+			Frame:       f.JPEG,
+			Timestamp:   f.Timestamp,
+			Crop:        f.Crop,
+			FrameWidth:  f.FrameWidth,
+			FrameHeight: f.FrameHeight,
+		})
+		if err != nil {
+			return nil, err
+		}
+		res.Frames++
+		if out.Face == nil || out.Judgement == nil {
+			if f.Judgement != nil {
+				res.Mismatches++
+			}
+			continue
+		}
+		res.Faces++
+		res.Judgements = append(res.Judgements, out.Judgement)
+		if out.Judgement.IsRepCompleted {
+			res.Reps++
+		}
+		if f.Judgement == nil || f.Judgement.State != out.Judgement.State || f.Judgement.IsRepCompleted != out.Judgement.IsRepCompleted {
+			res.Mismatches++
+		}
+	}
+	return res, nil
+}
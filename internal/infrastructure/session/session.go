@@ -0,0 +1,162 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"golang.org/x/xerrors"
+)
+
+// formatVersion は記録ファイルのフォーマットバージョン。
+const formatVersion = 1
+
+// RecordType は記録ファイル 1 行の種類。
+type RecordType string
+
+const (
+	RecordTypeHeader RecordType = "header"
+	RecordTypeFrame  RecordType = "frame"
+)
+
+// record は記録ファイル（JSON Lines）の 1 行。先頭が header、以降は frame が続く。
+type record struct {
+	Type      RecordType `json:"type"`
+	Timestamp time.Time  `json:"t"`
+
+	// header
+	Version int              `json:"version,omitempty"`
+	Privacy bool             `json:"privacy,omitempty"`
+	Setting *RecordedSetting `json:"setting,omitempty"`
+
+	// frame
+	JPEG        []byte            `json:"jpeg,omitempty"` // プライバシーモードでは保存しない
+	Crop        *entity.Rect      `json:"crop,omitempty"` // JPEG が元フレームを切り抜いたものなら、その範囲
+	FrameWidth  int               `json:"frameWidth,omitempty"`
+	FrameHeight int               `json:"frameHeight,omitempty"`
+	Face        *entity.Face      `json:"face,omitempty"` // nil は顔未検出。座標は元フレーム上
+	Judgement   *entity.Judgement `json:"judgement,omitempty"`
+}
+
+// Header は記録開始時点の情報。
+type Header struct {
+	StartedAt time.Time
+	Privacy   bool
+	Setting   *RecordedSetting
+}
+
+// RecordedSetting は記録に残す設定。記録は共有・再生するものなので、判定しきい値と取り込みの設定だけを残し、
+// API のトークンや MQTT のパスワード、Webhook の送信先は含めない（以前の記録の entity.Setting もそのまま読める）。
+type RecordedSetting struct {
+	TopRatio    float64
+	BottomRatio float64
+
+	CaptureWidth  int
+	CaptureHeight int
+	MaxFPS        float64
+	Rotation      int
+	Mirror        bool
+	ROI           entity.Region
+}
+
+func recordedSettingFrom(setting *entity.Setting) *RecordedSetting {
+	return &RecordedSetting{
+		TopRatio:      setting.TopRatio,
+		BottomRatio:   setting.BottomRatio,
+		CaptureWidth:  setting.CaptureWidth,
+		CaptureHeight: setting.CaptureHeight,
+		MaxFPS:        setting.MaxFPS,
+		Rotation:      setting.Rotation,
+		Mirror:        setting.Mirror,
+		ROI:           setting.ROI,
+	}
+}
+
+// Setting は再生に使う設定を返す。記録していない項目はデフォルトのまま。
+func (s *RecordedSetting) Setting() *entity.Setting {
+	setting := entity.DefaultSetting()
+	setting.TopRatio = s.TopRatio
+	setting.BottomRatio = s.BottomRatio
+	setting.CaptureWidth = s.CaptureWidth
+	setting.CaptureHeight = s.CaptureHeight
+	setting.MaxFPS = s.MaxFPS
+	setting.Rotation = s.Rotation
+	setting.Mirror = s.Mirror
+	setting.ROI = s.ROI
+	return setting
+}
+
+// Frame は記録された 1 フレーム分の入力と結果。
+type Frame struct {
+	Timestamp   time.Time
+	JPEG        []byte
+	Crop        *entity.Rect
+	FrameWidth  int
+	FrameHeight int
+	Face        *entity.Face
+	Judgement   *entity.Judgement
+}
+
+// Session は読み込んだ記録ファイル全体。
+type Session struct {
+	Header Header
+	Frames []*Frame
+}
+
+// RepCount は記録時に完了と判定された rep 数を返す。
+func (s *Session) RepCount() int {
+	count := 0
+	for _, f := range s.Frames {
+		if f.Judgement != nil && f.Judgement.IsRepCompleted {
+			count++
+		}
+	}
+	return count
+}
+
+// Load は記録ファイルを読み込む。
+func Load(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("session: open: %w", err)
+	}
+	defer f.Close()
+
+	s := &Session{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		var rec record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, xerrors.Errorf("session: %s:%d: %w", path, line, err)
+		}
+		switch rec.Type {
+		case RecordTypeHeader:
+			if rec.Version != formatVersion {
+				return nil, xerrors.Errorf("session: %s: unsupported format version %d", path, rec.Version)
+			}
+			s.Header = Header{StartedAt: rec.Timestamp, Privacy: rec.Privacy, Setting: rec.Setting}
+		case RecordTypeFrame:
+			s.Frames = append(s.Frames, &Frame{
+				Timestamp:   rec.Timestamp,
+				JPEG:        rec.JPEG,
+				Crop:        rec.Crop,
+				FrameWidth:  rec.FrameWidth,
+				FrameHeight: rec.FrameHeight,
+				Face:        rec.Face,
+				Judgement:   rec.Judgement,
+			})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, xerrors.Errorf("session: read: %w", err)
+	}
+	if s.Header.Setting == nil {
+		return nil, xerrors.Errorf("session: %s: missing header", path)
+	}
+	return s, nil
+}
@@ -0,0 +1,35 @@
+package simulator
+
+import (
+	"context"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+// FaceRepository はフレームの中身を見ずに、シミュレーターの軌跡から顔を返す FaceRepository。
+type FaceRepository struct {
+	sim *Simulator
+}
+
+func NewFaceRepository(sim *Simulator) repository.FaceRepository {
+	return &FaceRepository{sim: sim}
+}
+
+func (r *FaceRepository) Detect(ctx context.Context, frame []byte, t time.Time) (*entity.Face, error) {
+	faces := r.sim.Faces(t)
+	if len(faces) == 0 {
+		return nil, errors.ErrNotFound.Errorf("simulator: no face at %s", t.Format(time.RFC3339Nano))
+	}
+	// 実際の検出器はスコアが最も高い顔を選ぶ。シミュレーターの顔にはスコアが無いため、
+	// 手前で大きく映る本人の顔ほどスコアが高いとみなし、最も大きい顔を選ぶ
+	best := faces[0]
+	for _, f := range faces[1:] {
+		if f.Width*f.Height > best.Width*best.Height {
+			best = f
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,142 @@
+// Package simulator はカメラや顔検出ヘルパーなしでアプリを動かすためのデモ用シミュレーターを提供する。
+// スクワットする利用者の顔の軌跡を時刻の関数として生成し、FaceRepository と FrameSource の両方から同じ軌跡を返す。
+package simulator
+
+import (
+	"math"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+)
+
+const (
+	standingTopRatio = 0.3  // 立位での顔の上端の位置（フレームの高さに対する比率）
+	faceHeightRatio  = 0.28 // 顔の高さ（フレームの高さに対する比率）
+	faceAspect       = 0.8  // 顔の幅 / 高さ
+	passerbyScale    = 0.7  // 通行人の顔の大きさ（利用者に対する倍率）
+	dropoutBucket    = 200 * time.Millisecond
+)
+
+// Params はシミュレーションの設定。
+type Params struct {
+	FrameWidth       int
+	FrameHeight      int
+	Tempo            time.Duration // 1 rep（しゃがんで立つまで）にかかる時間
+	Rest             time.Duration // rep と rep の間に立ったまま休む時間
+	Depth            float64       // しゃがんだときに顔が下がる量（フレームの高さに対する比率）
+	Jitter           float64       // 顔の位置に加えるノイズの大きさ（ピクセル）
+	DropoutRate      float64       // 顔を見失う割合（0〜1）
+	PasserbyInterval time.Duration // 背後を別の人が通る間隔（0 なら通らない）
+	PasserbyDuration time.Duration // 通行人がフレームを横切るのにかかる時間
+	Seed             uint64
+}
+
+// Simulator は epoch からの経過時間に応じて顔の位置を決める。同じ Params と時刻からは常に同じ結果になる。
+type Simulator struct {
+	params Params
+	epoch  time.Time
+}
+
+func New(params Params, epoch time.Time) *Simulator {
+	return &Simulator{params: params, epoch: epoch}
+}
+
+// Params はシミュレーションの設定を返す。
+func (s *Simulator) Params() Params {
+	return s.params
+}
+
+// Faces は時刻 t にフレームに映っている顔を返す。利用者を見失っている間は通行人だけが含まれることがある。
+func (s *Simulator) Faces(t time.Time) entity.Faces {
+	elapsed := t.Sub(s.epoch)
+	var faces entity.Faces
+	if !s.droppedOut(elapsed) {
+		faces = append(faces, s.user(t, elapsed))
+	}
+	if p := s.passerby(t, elapsed); p != nil {
+		faces = append(faces, p)
+	}
+	return faces
+}
+
+// Depth は時刻 t のしゃがみの深さ（0 が立位、1 が最も深い位置）を返す。
+func (s *Simulator) Depth(t time.Time) float64 {
+	p := s.params
+	period := p.Tempo + p.Rest
+	if p.Tempo <= 0 || period <= 0 {
+		return 0
+	}
+	phase := t.Sub(s.epoch) % period
+	if phase < 0 {
+		phase += period
+	}
+	if phase >= p.Tempo {
+		return 0
+	}
+	// 下がって上がる動きを 1 周期のコサインで表す
+	return 0.5 - 0.5*math.Cos(2*math.Pi*float64(phase)/float64(p.Tempo))
+}
+
+func (s *Simulator) user(t time.Time, elapsed time.Duration) *entity.Face {
+	p := s.params
+	h := int(float64(p.FrameHeight) * faceHeightRatio)
+	w := int(float64(h) * faceAspect)
+	top := (standingTopRatio + p.Depth*s.Depth(t)) * float64(p.FrameHeight)
+	// 体の揺れを表す小さな横移動
+	sway := math.Sin(2*math.Pi*elapsed.Seconds()/7) * float64(p.FrameWidth) * 0.02
+	nano := uint64(t.UnixNano())
+	jx := (noise(p.Seed, nano, 1)*2 - 1) * p.Jitter
+	jy := (noise(p.Seed, nano, 2)*2 - 1) * p.Jitter
+	return &entity.Face{
+		Timestamp:   t,
+		X:           int(float64(p.FrameWidth-w)/2 + sway + jx),
+		Y:           int(top + jy),
+		Width:       w,
+		Height:      h,
+		FrameWidth:  p.FrameWidth,
+		FrameHeight: p.FrameHeight,
+	}
+}
+
+// passerby は背後を横切る別の人の顔を返す。横切っていない時刻は nil。
+func (s *Simulator) passerby(t time.Time, elapsed time.Duration) *entity.Face {
+	p := s.params
+	if p.PasserbyInterval <= 0 || p.PasserbyDuration <= 0 || elapsed < 0 {
+		return nil
+	}
+	phase := elapsed % p.PasserbyInterval
+	// 起動直後ではなく、各間隔の終わりに横切る
+	start := p.PasserbyInterval - p.PasserbyDuration
+	if phase < start {
+		return nil
+	}
+	progress := float64(phase-start) / float64(p.PasserbyDuration)
+	h := int(float64(p.FrameHeight) * faceHeightRatio * passerbyScale)
+	w := int(float64(h) * faceAspect)
+	return &entity.Face{
+		Timestamp:   t,
+		X:           int(progress*float64(p.FrameWidth+w)) - w,
+		Y:           int(float64(p.FrameHeight) * 0.2),
+		Width:       w,
+		Height:      h,
+		FrameWidth:  p.FrameWidth,
+		FrameHeight: p.FrameHeight,
+	}
+}
+
+// droppedOut は顔を見失っている区間かを返す。一瞬ではなく dropoutBucket 単位でまとめて見失う。
+func (s *Simulator) droppedOut(elapsed time.Duration) bool {
+	if s.params.DropoutRate <= 0 {
+		return false
+	}
+	return noise(s.params.Seed, uint64(elapsed/dropoutBucket), 3) < s.params.DropoutRate
+}
+
+// noise は seed と key から [0, 1) の決定的な擬似乱数を返す（splitmix64）。
+func noise(seed, key, stream uint64) float64 {
+	z := seed + key*0x9e3779b97f4a7c15 + stream*0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return float64(z>>11) / (1 << 53)
+}
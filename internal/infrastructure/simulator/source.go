@@ -0,0 +1,121 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/camera"
+)
+
+// 描画に使う色（YCbCr）
+var (
+	backgroundColor = [3]byte{70, 140, 120}
+	deskColor       = [3]byte{45, 120, 135}
+	skinColor       = [3]byte{170, 110, 150}
+	shirtColor      = [3]byte{90, 170, 110}
+	passerbyColor   = [3]byte{120, 115, 145}
+)
+
+// FrameSource はシミュレーターの顔を描いたフレームを流す FrameSource。
+// プレビューに利用者の動きが映るだけで、判定には FaceRepository の結果が使われる。
+type FrameSource struct {
+	sim *Simulator
+	fps float64
+}
+
+func NewFrameSource(sim *Simulator, fps float64) camera.FrameSource {
+	return &FrameSource{sim: sim, fps: fps}
+}
+
+func (s *FrameSource) ListDevices() ([]camera.Device, error) {
+	return []camera.Device{{Index: 0, Name: "デモ（シミュレーション）", ID: "demo", Live: true}}, nil
+}
+
+func (s *FrameSource) Open(ctx context.Context, deviceIndex int) (camera.Stream, error) {
+	if deviceIndex != 0 {
+		return nil, fmt.Errorf("simulator: device index %d out of range (0..0)", deviceIndex)
+	}
+	if s.fps <= 0 {
+		return nil, fmt.Errorf("simulator: fps must be positive, got %v", s.fps)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan camera.Frame, 1)
+	go s.run(ctx, out)
+	return camera.NewChanStream(out, cancel), nil
+}
+
+func (s *FrameSource) Capabilities() camera.Capabilities {
+	return camera.Capabilities{Supported: true, Live: true}
+}
+
+func (s *FrameSource) run(ctx context.Context, out chan<- camera.Frame) {
+	defer close(out)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / s.fps))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			select {
+			case out <- s.render(t):
+			case <-ctx.Done():
+				return
+			default:
+				// 処理が追いつかない場合はドロップ
+			}
+		}
+	}
+}
+
+// render は時刻 t の顔を頭と胴体の図形として描く。
+func (s *FrameSource) render(t time.Time) camera.Frame {
+	p := s.sim.Params()
+	w, h := p.FrameWidth, p.FrameHeight
+	data := camera.NewFrameBuffer(w * h * 3)
+	deskTop := h * 4 / 5
+	for y := 0; y < h; y++ {
+		c := backgroundColor
+		if y >= deskTop {
+			c = deskColor
+		}
+		for x := 0; x < w; x++ {
+			copy(data[(y*w+x)*3:], c[:])
+		}
+	}
+	faces := s.sim.Faces(t)
+	// 通行人は後ろにいるため先に描く（利用者を見失っている間は通行人だけになる）
+	for i := len(faces) - 1; i >= 0; i-- {
+		f := faces[i]
+		head, body := skinColor, shirtColor
+		if f.Height < int(float64(h)*faceHeightRatio) {
+			head, body = passerbyColor, passerbyColor
+		}
+		fillRect(data, w, h, f.X-f.Width/4, f.Y+f.Height, f.Width*3/2, h-f.Y-f.Height, body)
+		fillEllipse(data, w, h, f, head)
+	}
+	return camera.Frame{Data: data, Width: w, Height: h, Timestamp: t}
+}
+
+func fillRect(data []byte, w, h, x0, y0, rw, rh int, c [3]byte) {
+	for y := max(y0, 0); y < min(y0+rh, h); y++ {
+		for x := max(x0, 0); x < min(x0+rw, w); x++ {
+			copy(data[(y*w+x)*3:], c[:])
+		}
+	}
+}
+
+func fillEllipse(data []byte, w, h int, f *entity.Face, c [3]byte) {
+	rx, ry := float64(f.Width)/2, float64(f.Height)/2
+	cx, cy := float64(f.X)+rx, float64(f.Y)+ry
+	for y := max(f.Y, 0); y < min(f.Y+f.Height, h); y++ {
+		for x := max(f.X, 0); x < min(f.X+f.Width, w); x++ {
+			dx, dy := (float64(x)+0.5-cx)/rx, (float64(y)+0.5-cy)/ry
+			if dx*dx+dy*dy <= 1 {
+				copy(data[(y*w+x)*3:], c[:])
+			}
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Package webhook は WorkoutEvent の Webhook をファイルに保存したキューから送る。
+// 送れなかったものは間隔を倍にしながら再送し、アプリを再起動してもキューから続きを送る。
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/file"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	maxQueueSize   = 1000 // これを超えたら古いものから捨てる
+	maxAttempts    = 10   // これだけ失敗したら諦める
+	baseBackoff    = 5 * time.Second
+	maxBackoff     = 10 * time.Minute
+)
+
+// Dispatcher は repository.WebhookDeliveryRepository の実装。Run を呼んでいる間、キューを送り続ける。
+// 同じ Webhook への送信は、前のものが送れるまで後のものを送らない（順序を保つ）。
+// Webhook ごとに並行して送るため、応答しない送信先があってもほかの Webhook は待たされない。
+type Dispatcher struct {
+	client *http.Client
+	path   string
+
+	mu      sync.Mutex
+	queue   []*entity.WebhookDelivery
+	sending map[string]*entity.WebhookDelivery // Webhook ID ごとの送信中のもの
+	wake    chan struct{}
+}
+
+// NewDispatcher は path に保存したキューを読み込んで Dispatcher を返す。読めないキューは捨てて空から始める。
+func NewDispatcher(path string) (*Dispatcher, error) {
+	d := &Dispatcher{
+		client:  &http.Client{Timeout: requestTimeout},
+		path:    path,
+		sending: make(map[string]*entity.WebhookDelivery),
+		wake:    make(chan struct{}, 1),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("webhook: read queue: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &d.queue); err != nil {
+			log.Printf("webhook: discard broken queue %s: %v", path, err)
+			d.queue = nil
+		}
+	}
+	return d, nil
+}
+
+var _ repository.WebhookDeliveryRepository = (*Dispatcher)(nil)
+
+func (d *Dispatcher) Enqueue(delivery *entity.WebhookDelivery) error {
+	queued := *delivery
+	if queued.ID == "" {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return fmt.Errorf("webhook: generate delivery id: %w", err)
+		}
+		queued.ID = hex.EncodeToString(b)
+	}
+	d.mu.Lock()
+	d.queue = append(d.queue, &queued)
+	if over := len(d.queue) - maxQueueSize; over > 0 {
+		log.Printf("webhook: queue is full, dropping %d oldest deliveries", over)
+		d.queue = d.queue[over:]
+	}
+	err := d.saveLocked()
+	d.mu.Unlock()
+	d.signal()
+	return err
+}
+
+// signal は Run の待ちを起こす。
+func (d *Dispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) Retarget(webhook *entity.Webhook) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var queue []*entity.WebhookDelivery
+	for _, queued := range d.queue {
+		if queued.WebhookID != webhook.ID {
+			queue = append(queue, queued)
+			continue
+		}
+		if !webhook.Enabled || !webhook.Subscribes(queued.Event) {
+			continue
+		}
+		rendered, err := webhook.Render(queued.Source)
+		if err != nil {
+			log.Printf("webhook: drop delivery %s: %v", queued.ID, err)
+			continue
+		}
+		// 送信中のものとは別のポインタにする。ID は同じなので、送信中のものが届けばこちらも外れる
+		rendered.ID = queued.ID
+		rendered.CreatedAt = queued.CreatedAt
+		queue = append(queue, rendered)
+	}
+	d.queue = queue
+	err := d.saveLocked()
+	d.signal()
+	return err
+}
+
+func (d *Dispatcher) Discard(webhookID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue = slices.DeleteFunc(d.queue, func(queued *entity.WebhookDelivery) bool {
+		return queued.WebhookID == webhookID
+	})
+	return d.saveLocked()
+}
+
+func (d *Dispatcher) Send(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, strings.NewReader(delivery.Body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", delivery.ContentType)
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	if delivery.ID != "" {
+		// 受け手が再送を見分けられるよう、同じ配送には同じ ID を付ける
+		req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	}
+	res, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook: %s responded %s", delivery.URL, res.Status)
+	}
+	return nil
+}
+
+// Run は ctx が終わるまで、送る時刻になったものを送り続ける。送信は Webhook ごとのゴルーチンで行い、
+// ctx が終わったら送信中のものが戻るのを待ってから返る。
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		for _, delivery := range d.due(time.Now()) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := d.Send(ctx, delivery)
+				if ctx.Err() != nil {
+					// 終了で中断したものは失敗に数えず、次回の起動時に送り直す
+					d.release(delivery)
+					return
+				}
+				d.finish(delivery, err)
+				d.signal()
+			}()
+		}
+		wait := d.nextWait(time.Now())
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// due はいま送るものを返し、送信中にする。送信中・再送を待っている Webhook の後ろにあるものは、順序を保つため含めない。
+func (d *Dispatcher) due(now time.Time) []*entity.WebhookDelivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	blocked := make(map[string]bool)
+	var due []*entity.WebhookDelivery
+	for _, delivery := range d.queue {
+		if blocked[delivery.WebhookID] || d.sending[delivery.WebhookID] != nil {
+			continue
+		}
+		if delivery.NextAttempt.After(now) {
+			blocked[delivery.WebhookID] = true
+			continue
+		}
+		due = append(due, delivery)
+		d.sending[delivery.WebhookID] = delivery
+		// 同じ Webhook の次のものは、これの結果を見てから送る
+		blocked[delivery.WebhookID] = true
+	}
+	return due
+}
+
+// release は送信中の印だけを外す。
+func (d *Dispatcher) release(delivery *entity.WebhookDelivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.releaseLocked(delivery)
+}
+
+func (d *Dispatcher) releaseLocked(delivery *entity.WebhookDelivery) {
+	if d.sending[delivery.WebhookID] == delivery {
+		delete(d.sending, delivery.WebhookID)
+	}
+}
+
+// finish は送った結果を反映する。成功か諦めたものはキューから外し、失敗したものは次の時刻を決める。
+func (d *Dispatcher) finish(delivery *entity.WebhookDelivery, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.releaseLocked(delivery)
+	idx := slices.IndexFunc(d.queue, func(queued *entity.WebhookDelivery) bool { return queued.ID == delivery.ID })
+	if idx < 0 {
+		// 送っている間に Webhook が削除された
+		return
+	}
+	if err != nil && d.queue[idx] != delivery {
+		// 送っている間に Webhook が編集された。作り直したものを改めて送る
+		return
+	}
+	if err != nil {
+		delivery.Attempts++
+		delivery.LastError = err.Error()
+		if delivery.Attempts < maxAttempts {
+			delivery.NextAttempt = time.Now().Add(backoff(delivery.Attempts))
+			log.Printf("%v (attempt %d, retry at %s)", err, delivery.Attempts, delivery.NextAttempt.Format(time.TimeOnly))
+			if err := d.saveLocked(); err != nil {
+				log.Println(err)
+			}
+			return
+		}
+		log.Printf("webhook: giving up delivery %s to %s after %d attempts: %v", delivery.ID, delivery.URL, delivery.Attempts, err)
+	}
+	d.queue = slices.Delete(d.queue, idx, idx+1)
+	if err := d.saveLocked(); err != nil {
+		log.Println(err)
+	}
+}
+
+// nextWait は次に送れるようになるまでの時間。Webhook ごとに先頭のものだけを見る。
+// 送信中の Webhook は送り終えたときに起こされるので見ない。キューが空なら Enqueue まで待つ。
+func (d *Dispatcher) nextWait(now time.Time) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	wait := maxBackoff
+	seen := make(map[string]bool)
+	for _, delivery := range d.queue {
+		if seen[delivery.WebhookID] {
+			continue
+		}
+		seen[delivery.WebhookID] = true
+		if d.sending[delivery.WebhookID] != nil {
+			continue
+		}
+		wait = min(wait, max(0, delivery.NextAttempt.Sub(now)))
+	}
+	return wait
+}
+
+// saveLocked はキューをファイルに書き出す。書きかけのファイルが残らないよう、file.WriteAtomic で置き換える。
+func (d *Dispatcher) saveLocked() error {
+	data, err := json.Marshal(d.queue)
+	if err != nil {
+		return fmt.Errorf("webhook: encode queue: %w", err)
+	}
+	if err := file.WriteAtomic(d.path, data); err != nil {
+		return fmt.Errorf("webhook: save queue: %w", err)
+	}
+	return nil
+}
+
+// backoff は attempts 回失敗したあとに待つ時間（5 秒から倍々で最大 10 分）。
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff << (attempts - 1)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+)
+
+// recorder は受け取ったリクエストの本文を順に記録する httptest のハンドラー。
+type recorder struct {
+	mu     sync.Mutex
+	bodies []string
+	got    chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{got: make(chan struct{}, 100)}
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.bodies = append(r.bodies, string(body))
+	r.mu.Unlock()
+	r.got <- struct{}{}
+}
+
+func (r *recorder) wait(t *testing.T, n int, timeout time.Duration) []string {
+	t.Helper()
+	deadline := time.After(timeout)
+	for range n {
+		select {
+		case <-r.got:
+		case <-deadline:
+			t.Fatalf("received %d of %d requests within %s", len(r.snapshot()), n, timeout)
+		}
+	}
+	return r.snapshot()
+}
+
+func (r *recorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+func newTestDispatcher(t *testing.T) *Dispatcher {
+	t.Helper()
+	d, err := NewDispatcher(filepath.Join(t.TempDir(), "webhook_queue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func runDispatcher(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func delivery(webhookID, url, body string) *entity.WebhookDelivery {
+	return &entity.WebhookDelivery{WebhookID: webhookID, URL: url, ContentType: "text/plain", Body: body, Event: entity.WorkoutEventRep}
+}
+
+func TestDispatcher_SlowWebhookDoesNotDelayOthers(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := newRecorder()
+	fastSrv := httptest.NewServer(fast)
+	defer fastSrv.Close()
+
+	d := newTestDispatcher(t)
+	runDispatcher(t, d)
+	if err := d.Enqueue(delivery("slow", slow.URL, "stuck")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Enqueue(delivery("fast", fastSrv.URL, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Enqueue(delivery("fast", fastSrv.URL, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 応答しない送信先のタイムアウト（10 秒）を待たずに、ほかの Webhook へは順番どおり届く
+	got := fast.wait(t, 2, 2*time.Second)
+	if got[0] != "a" || got[1] != "b" {
+		t.Errorf("fast webhook received %v, want [a b]", got)
+	}
+}
+
+func TestDispatcher_FailedDeliveryIsKeptForRetry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "webhook_queue.json")
+	d, err := NewDispatcher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Enqueue(delivery("w1", srv.URL, "x")); err != nil {
+		t.Fatal(err)
+	}
+	due := d.due(time.Now())
+	if len(due) != 1 {
+		t.Fatalf("due = %d deliveries, want 1", len(due))
+	}
+	d.finish(due[0], d.Send(context.Background(), due[0]))
+
+	// 再起動しても、同じ ID のまま次の時刻を待って再送する
+	reloaded, err := NewDispatcher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.queue) != 1 {
+		t.Fatalf("queue after restart = %d deliveries, want 1", len(reloaded.queue))
+	}
+	q := reloaded.queue[0]
+	if q.ID != due[0].ID || q.Attempts != 1 || q.LastError == "" {
+		t.Errorf("queued = %+v, want the same ID with 1 failed attempt", q)
+	}
+	if wait := reloaded.nextWait(time.Now()); wait <= 0 || wait > baseBackoff {
+		t.Errorf("nextWait = %s, want within (0, %s]", wait, baseBackoff)
+	}
+}
+
+func TestDispatcher_RetargetAndDiscard(t *testing.T) {
+	d := newTestDispatcher(t)
+	old := entity.Webhook{ID: "w1", URL: "http://127.0.0.1:1/old", Enabled: true}
+	for _, ev := range []entity.WorkoutEvent{
+		{Type: entity.WorkoutEventRep, TodayCount: 1},
+		{Type: entity.WorkoutEventSet, TodayCount: 1, SetReps: 1},
+	} {
+		delivery, err := old.Render(ev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Enqueue(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := &entity.WebhookDelivery{WebhookID: "w2", URL: "http://127.0.0.1:1/other", Event: entity.WorkoutEventRep}
+	if err := d.Enqueue(other); err != nil {
+		t.Fatal(err)
+	}
+	// 先頭は送信中のまま編集される
+	inflight := d.due(time.Now())[0]
+
+	edited := old
+	edited.URL = "http://127.0.0.1:1/new"
+	edited.Template = "{{.Type}} {{.TodayCount}}"
+	edited.ContentType = "text/plain"
+	edited.Events = []entity.WorkoutEventType{entity.WorkoutEventRep}
+	if err := d.Retarget(&edited); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.queue) != 2 {
+		t.Fatalf("queue = %d deliveries, want 2 (the unsubscribed set is dropped)", len(d.queue))
+	}
+	q := d.queue[0]
+	if q.ID != inflight.ID || q.URL != edited.URL || q.Body != "rep 1" || q.ContentType != "text/plain" {
+		t.Errorf("retargeted = %+v, want the same ID rendered for the edited webhook", q)
+	}
+	if d.queue[1].WebhookID != "w2" {
+		t.Errorf("other webhook's delivery was touched: %+v", d.queue[1])
+	}
+
+	// 送信中だった編集前のものが届いたら、作り直した同じ ID のものは送らない
+	d.finish(inflight, nil)
+	if len(d.queue) != 1 || d.queue[0].WebhookID != "w2" {
+		t.Fatalf("queue after the in-flight delivery succeeded = %+v, want only w2", d.queue)
+	}
+
+	if err := d.Discard("w2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.queue) != 0 {
+		t.Fatalf("queue after Discard = %d deliveries, want 0", len(d.queue))
+	}
+}
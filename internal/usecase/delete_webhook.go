@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+type DeleteWebhookInputPort interface {
+	Execute(ctx context.Context, id string) error
+}
+
+type DeleteWebhookInteractor struct {
+	SettingRepository         repository.SettingRepository
+	WebhookDeliveryRepository repository.WebhookDeliveryRepository
+}
+
+func NewDeleteWebhookUsecase(settingRepository repository.SettingRepository, webhookDeliveryRepository repository.WebhookDeliveryRepository) DeleteWebhookInputPort {
+	return &DeleteWebhookInteractor{
+		SettingRepository:         settingRepository,
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+	}
+}
+
+// Execute は Webhook を削除し、送信キューに残っている分も捨てる。
+func (i *DeleteWebhookInteractor) Execute(ctx context.Context, id string) error {
+	err := i.SettingRepository.Update(func(setting *entity.Setting) error {
+		idx := webhookIndex(setting.Webhooks, id)
+		if idx < 0 {
+			return errors.ErrNotFound.Errorf("webhook %s", id)
+		}
+		setting.Webhooks = slices.Delete(setting.Webhooks, idx, idx+1)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return i.WebhookDeliveryRepository.Discard(id)
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
 	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
 )
 
@@ -13,6 +14,41 @@ type GetSettingInputPort interface {
 type GetSettingOutput struct {
 	TopRatio    float64
 	BottomRatio float64
+	CameraID    string
+
+	CaptureWidth  int
+	CaptureHeight int
+	MaxFPS        float64
+	Rotation      int
+	Mirror        bool
+
+	ROI entity.Region
+	API entity.APISetting
+
+	DailyGoal int
+	MQTT      MQTTSettingOutput
+}
+
+// MQTTSettingOutput は MQTT の設定。パスワードは返さず、設定してあるかだけを返す。
+type MQTTSettingOutput struct {
+	Enabled         bool
+	Broker          string
+	Username        string
+	HasPassword     bool
+	TopicPrefix     string
+	DiscoveryPrefix string
+}
+
+// MQTTSettingOutputFrom はパスワードを除いた MQTTSettingOutput を返す。
+func MQTTSettingOutputFrom(conf entity.MQTTSetting) MQTTSettingOutput {
+	return MQTTSettingOutput{
+		Enabled:         conf.Enabled,
+		Broker:          conf.Broker,
+		Username:        conf.Username,
+		HasPassword:     conf.Password != "",
+		TopicPrefix:     conf.TopicPrefix,
+		DiscoveryPrefix: conf.DiscoveryPrefix,
+	}
 }
 
 type GetSettingInteractor struct {
@@ -33,5 +69,18 @@ func (i *GetSettingInteractor) Execute(ctx context.Context) (*GetSettingOutput,
 	return &GetSettingOutput{
 		TopRatio:    setting.TopRatio,
 		BottomRatio: setting.BottomRatio,
+		CameraID:    setting.CameraID,
+
+		CaptureWidth:  setting.CaptureWidth,
+		CaptureHeight: setting.CaptureHeight,
+		MaxFPS:        setting.MaxFPS,
+		Rotation:      setting.Rotation,
+		Mirror:        setting.Mirror,
+
+		ROI: setting.ROI,
+		API: setting.API,
+
+		DailyGoal: setting.DailyGoal,
+		MQTT:      MQTTSettingOutputFrom(setting.MQTT),
 	}, nil
 }
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+
+	"cloud.google.com/go/civil"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+const maxStatsRangeDays = 366
+
+type GetStatsRangeInputPort interface {
+	Execute(ctx context.Context, from, to civil.Date) (*GetStatsRangeOutput, error)
+}
+
+type GetStatsRangeOutput struct {
+	Days     []DailyStats // from〜to の各日（rep のない日も 0 で含める）
+	RepCount int          // 期間の合計
+}
+
+type DailyStats struct {
+	Date     civil.Date
+	RepCount int
+}
+
+type GetStatsRangeInteractor struct {
+	JudgementRepository repository.JudgementRepository
+}
+
+func NewGetStatsRangeUsecase(judgementRepository repository.JudgementRepository) GetStatsRangeInputPort {
+	return &GetStatsRangeInteractor{
+		JudgementRepository: judgementRepository,
+	}
+}
+
+func (i *GetStatsRangeInteractor) Execute(ctx context.Context, from, to civil.Date) (*GetStatsRangeOutput, error) {
+	if !from.IsValid() || !to.IsValid() {
+		return nil, errors.ErrInvalidArgument.Errorf("from and to must be valid dates (from=%s, to=%s)", from, to)
+	}
+	if to.Before(from) {
+		return nil, errors.ErrInvalidArgument.Errorf("to must not be before from (from=%s, to=%s)", from, to)
+	}
+	if days := to.DaysSince(from) + 1; days > maxStatsRangeDays {
+		return nil, errors.ErrInvalidArgument.Errorf("range must be at most %d days, got %d", maxStatsRangeDays, days)
+	}
+	counts, err := i.JudgementRepository.CountRepsByDateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	out := &GetStatsRangeOutput{}
+	for date := from; !date.After(to); date = date.AddDays(1) {
+		out.Days = append(out.Days, DailyStats{Date: date, RepCount: counts[date]})
+		out.RepCount += counts[date]
+	}
+	return out, nil
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+)
+
+type GetWebhooksInputPort interface {
+	Execute(ctx context.Context) ([]entity.Webhook, error)
+}
+
+type GetWebhooksInteractor struct {
+	SettingRepository repository.SettingRepository
+}
+
+func NewGetWebhooksUsecase(settingRepository repository.SettingRepository) GetWebhooksInputPort {
+	return &GetWebhooksInteractor{
+		SettingRepository: settingRepository,
+	}
+}
+
+func (i *GetWebhooksInteractor) Execute(ctx context.Context) ([]entity.Webhook, error) {
+	setting, err := i.SettingRepository.Get()
+	if err != nil {
+		return nil, err
+	}
+	if setting.Webhooks == nil {
+		return []entity.Webhook{}, nil
+	}
+	return setting.Webhooks, nil
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/domain/service"
+)
+
+type NotifyWorkoutInputPort interface {
+	// Execute は判定から rep・セット・目標の出来事を見つけ、購読している Webhook の送信キューに入れる。
+	Execute(ctx context.Context, judgement *entity.Judgement) ([]entity.WorkoutEvent, error)
+	// Flush はキャプチャを止めたときに呼び、続いているセットを終わらせて通知する。
+	Flush(ctx context.Context) ([]entity.WorkoutEvent, error)
+}
+
+type NotifyWorkoutInteractor struct {
+	WorkoutTracker            service.WorkoutTracker
+	SettingRepository         repository.SettingRepository
+	WebhookDeliveryRepository repository.WebhookDeliveryRepository
+	// OnExpire は休憩でセットが終わったとき、通知したあとに呼ぶ（判定を待たずにタイマーから呼ばれる）。
+	OnExpire func(events []entity.WorkoutEvent)
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+func NewNotifyWorkoutUsecase(workoutTracker service.WorkoutTracker, settingRepository repository.SettingRepository, webhookDeliveryRepository repository.WebhookDeliveryRepository, onExpire func(events []entity.WorkoutEvent)) NotifyWorkoutInputPort {
+	return &NotifyWorkoutInteractor{
+		WorkoutTracker:            workoutTracker,
+		SettingRepository:         settingRepository,
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+		OnExpire:                  onExpire,
+	}
+}
+
+func (i *NotifyWorkoutInteractor) Execute(ctx context.Context, judgement *entity.Judgement) ([]entity.WorkoutEvent, error) {
+	events, err := i.WorkoutTracker.Observe(judgement)
+	if err != nil {
+		return nil, err
+	}
+	i.schedule()
+	return events, i.notify(events)
+}
+
+func (i *NotifyWorkoutInteractor) Flush(ctx context.Context) ([]entity.WorkoutEvent, error) {
+	events, err := i.WorkoutTracker.Flush()
+	if err != nil {
+		return nil, err
+	}
+	i.schedule()
+	return events, i.notify(events)
+}
+
+// schedule は続いているセットが休憩で終わる時刻にタイマーを合わせ直す。セットが無ければ止める。
+func (i *NotifyWorkoutInteractor) schedule() {
+	deadline, ok := i.WorkoutTracker.RestDeadline()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.timer != nil {
+		i.timer.Stop()
+		i.timer = nil
+	}
+	if ok {
+		i.timer = time.AfterFunc(time.Until(deadline), i.expire)
+	}
+}
+
+func (i *NotifyWorkoutInteractor) expire() {
+	// 止めたあとに発火したタイマーや、次の rep で延びたセットは Expire が何も返さない
+	events, err := i.WorkoutTracker.Expire(time.Now())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+	if err := i.notify(events); err != nil {
+		log.Println(err)
+	}
+	if i.OnExpire != nil {
+		i.OnExpire(events)
+	}
+}
+
+func (i *NotifyWorkoutInteractor) notify(events []entity.WorkoutEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	setting, err := i.SettingRepository.Get()
+	if err != nil {
+		return err
+	}
+	for _, webhook := range setting.Webhooks {
+		if !webhook.Enabled {
+			continue
+		}
+		for _, ev := range events {
+			if !webhook.Subscribes(ev.Type) {
+				continue
+			}
+			delivery, err := webhook.Render(ev)
+			if err != nil {
+				// テンプレートの実行時エラーはその Webhook だけの問題なので、ほかの送信は続ける
+				log.Println(err)
+				continue
+			}
+			if err := i.WebhookDeliveryRepository.Enqueue(delivery); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/service"
+	"github.com/kikils/desk-squat-tracker/internal/infrastructure/memory"
+)
+
+// fakeDeliveries は Enqueue されたものを覚えておく WebhookDeliveryRepository。
+type fakeDeliveries struct {
+	mu       sync.Mutex
+	enqueued []*entity.WebhookDelivery
+}
+
+func (f *fakeDeliveries) Enqueue(delivery *entity.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enqueued = append(f.enqueued, delivery)
+	return nil
+}
+
+func (f *fakeDeliveries) Send(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	return nil
+}
+
+func (f *fakeDeliveries) Retarget(webhook *entity.Webhook) error {
+	return nil
+}
+
+func (f *fakeDeliveries) Discard(webhookID string) error {
+	return nil
+}
+
+func (f *fakeDeliveries) events() []entity.WorkoutEventType {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var types []entity.WorkoutEventType
+	for _, d := range f.enqueued {
+		types = append(types, d.Event)
+	}
+	return types
+}
+
+func TestNotifyWorkout_EndsSetAfterRestWithoutJudgements(t *testing.T) {
+	const setRest = 50 * time.Millisecond
+	judgements := memory.NewJudgementRepository()
+	settings := memory.NewSettingRepository()
+	if err := settings.Update(func(s *entity.Setting) error {
+		s.Webhooks = []entity.Webhook{{ID: "w1", URL: "http://127.0.0.1/hook", Enabled: true, Events: []entity.WorkoutEventType{entity.WorkoutEventSet}}}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := &fakeDeliveries{}
+	expired := make(chan []entity.WorkoutEvent, 1)
+	notify := NewNotifyWorkoutUsecase(service.NewWorkoutTracker(judgements, settings, setRest), settings, deliveries, func(events []entity.WorkoutEvent) {
+		expired <- events
+	})
+
+	for range 2 {
+		j := &entity.Judgement{Timestamp: time.Now(), State: entity.DetectStateStanding, IsRepCompleted: true}
+		if err := judgements.Save(j); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := notify.Execute(context.Background(), j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := deliveries.events(); len(got) != 0 {
+		t.Fatalf("enqueued %v before the rest elapsed", got)
+	}
+
+	// 顔が映らなくなり判定が来なくても、休憩が SetRest 続いたらセットが終わる
+	select {
+	case events := <-expired:
+		if len(events) != 1 || events[0].Type != entity.WorkoutEventSet || events[0].SetReps != 2 {
+			t.Fatalf("expired events = %+v, want one set of 2 reps", events)
+		}
+	case <-time.After(20 * setRest):
+		t.Fatal("set did not end after the rest without judgements")
+	}
+	if got := deliveries.events(); len(got) != 1 || got[0] != entity.WorkoutEventSet {
+		t.Fatalf("enqueued %v, want [set]", got)
+	}
+
+	// Flush 済みのセットは、あとからタイマーで二重に終わらせない
+	if events, err := notify.Flush(context.Background()); err != nil || len(events) != 0 {
+		t.Fatalf("Flush = %v, %v; want nothing", events, err)
+	}
+}
+
+func TestNotifyWorkout_FlushStopsTimer(t *testing.T) {
+	const setRest = 50 * time.Millisecond
+	judgements := memory.NewJudgementRepository()
+	settings := memory.NewSettingRepository()
+	expired := make(chan []entity.WorkoutEvent, 1)
+	notify := NewNotifyWorkoutUsecase(service.NewWorkoutTracker(judgements, settings, setRest), settings, &fakeDeliveries{}, func(events []entity.WorkoutEvent) {
+		expired <- events
+	})
+
+	j := &entity.Judgement{Timestamp: time.Now(), State: entity.DetectStateStanding, IsRepCompleted: true}
+	if err := judgements.Save(j); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notify.Execute(context.Background(), j); err != nil {
+		t.Fatal(err)
+	}
+	events, err := notify.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != entity.WorkoutEventSet {
+		t.Fatalf("Flush = %+v, want one set", events)
+	}
+	select {
+	case events := <-expired:
+		t.Fatalf("timer ended the set again after Flush: %+v", events)
+	case <-time.After(4 * setRest):
+	}
+}
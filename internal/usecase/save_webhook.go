@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+type SaveWebhookInputPort interface {
+	// Execute は ID が空なら Webhook を追加し、あればその Webhook を置き換える。保存後の Webhook を返す。
+	Execute(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error)
+}
+
+type SaveWebhookInteractor struct {
+	SettingRepository         repository.SettingRepository
+	WebhookDeliveryRepository repository.WebhookDeliveryRepository
+}
+
+func NewSaveWebhookUsecase(settingRepository repository.SettingRepository, webhookDeliveryRepository repository.WebhookDeliveryRepository) SaveWebhookInputPort {
+	return &SaveWebhookInteractor{
+		SettingRepository:         settingRepository,
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+	}
+}
+
+func (i *SaveWebhookInteractor) Execute(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	if err := webhook.Validate(); err != nil {
+		return nil, errors.ErrInvalidArgument.Errorf("%v", err)
+	}
+	saved := *webhook
+	replaced := saved.ID != ""
+	err := i.SettingRepository.Update(func(setting *entity.Setting) error {
+		if saved.ID == "" {
+			b := make([]byte, 8)
+			if _, err := rand.Read(b); err != nil {
+				return fmt.Errorf("generate webhook id: %w", err)
+			}
+			saved.ID = hex.EncodeToString(b)
+			setting.Webhooks = append(setting.Webhooks, saved)
+			return nil
+		}
+		idx := webhookIndex(setting.Webhooks, saved.ID)
+		if idx < 0 {
+			return errors.ErrNotFound.Errorf("webhook %s", saved.ID)
+		}
+		setting.Webhooks[idx] = saved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if replaced {
+		// 送れずに残っている分も、編集後の宛先・本文で送る
+		if err := i.WebhookDeliveryRepository.Retarget(&saved); err != nil {
+			return nil, err
+		}
+	}
+	return &saved, nil
+}
+
+func webhookIndex(webhooks []entity.Webhook, id string) int {
+	for idx, w := range webhooks {
+		if w.ID == id {
+			return idx
+		}
+	}
+	return -1
+}
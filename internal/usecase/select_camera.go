@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+)
+
+type SelectCameraInputPort interface {
+	Execute(ctx context.Context, cameraID string) error
+}
+
+type SelectCameraInteractor struct {
+	SettingRepository repository.SettingRepository
+}
+
+func NewSelectCameraUsecase(settingRepository repository.SettingRepository) SelectCameraInputPort {
+	return &SelectCameraInteractor{
+		SettingRepository: settingRepository,
+	}
+}
+
+// Execute は選択したカメラの ID を設定に保存する。次回起動時にこのカメラで自動的にキャプチャを開始する。
+func (i *SelectCameraInteractor) Execute(ctx context.Context, cameraID string) error {
+	if cameraID == "" {
+		return fmt.Errorf("cameraID must not be empty")
+	}
+	return i.SettingRepository.Update(func(setting *entity.Setting) error {
+		setting.CameraID = cameraID
+		return nil
+	})
+}
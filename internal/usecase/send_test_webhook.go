@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+type SendTestWebhookInputPort interface {
+	// Execute は test の出来事を、キューを通さずにすぐ送る。送れなければそのエラーを返す。
+	Execute(ctx context.Context, id string) error
+}
+
+type SendTestWebhookInteractor struct {
+	SettingRepository         repository.SettingRepository
+	JudgementRepository       repository.JudgementRepository
+	WebhookDeliveryRepository repository.WebhookDeliveryRepository
+}
+
+func NewSendTestWebhookUsecase(settingRepository repository.SettingRepository, judgementRepository repository.JudgementRepository, webhookDeliveryRepository repository.WebhookDeliveryRepository) SendTestWebhookInputPort {
+	return &SendTestWebhookInteractor{
+		SettingRepository:         settingRepository,
+		JudgementRepository:       judgementRepository,
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+	}
+}
+
+func (i *SendTestWebhookInteractor) Execute(ctx context.Context, id string) error {
+	setting, err := i.SettingRepository.Get()
+	if err != nil {
+		return err
+	}
+	idx := webhookIndex(setting.Webhooks, id)
+	if idx < 0 {
+		return errors.ErrNotFound.Errorf("webhook %s", id)
+	}
+	now := time.Now()
+	todayCount, err := i.JudgementRepository.CountRepsByDate(civil.DateOf(now))
+	if err != nil {
+		return err
+	}
+	// テンプレートを確かめられるよう、実際の出来事と同じ項目を埋める
+	delivery, err := setting.Webhooks[idx].Render(entity.WorkoutEvent{
+		Type:       entity.WorkoutEventTest,
+		Timestamp:  now,
+		TodayCount: todayCount,
+		SetReps:    1,
+		Goal:       setting.DailyGoal,
+	})
+	if err != nil {
+		return errors.ErrInvalidArgument.Errorf("%v", err)
+	}
+	return i.WebhookDeliveryRepository.Send(ctx, delivery)
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+const apiTokenBytes = 24
+
+type UpdateAPISettingInput struct {
+	Enabled bool
+	Port    int
+	// RegenerateToken なら新しいトークンを発行し、以前のトークンを使えなくする
+	RegenerateToken bool
+}
+
+type UpdateAPISettingInputPort interface {
+	Execute(ctx context.Context, in *UpdateAPISettingInput) (*entity.APISetting, error)
+}
+
+type UpdateAPISettingInteractor struct {
+	SettingRepository repository.SettingRepository
+}
+
+func NewUpdateAPISettingUsecase(settingRepository repository.SettingRepository) UpdateAPISettingInputPort {
+	return &UpdateAPISettingInteractor{
+		SettingRepository: settingRepository,
+	}
+}
+
+// Execute は API の設定を保存し、保存後の設定（トークンを含む）を返す。有効にするときトークンがなければ発行する。
+func (i *UpdateAPISettingInteractor) Execute(ctx context.Context, in *UpdateAPISettingInput) (*entity.APISetting, error) {
+	if !entity.ValidPort(in.Port) {
+		return nil, errors.ErrInvalidArgument.Errorf("port must be in [1024, 65535], got %d", in.Port)
+	}
+	var saved entity.APISetting
+	err := i.SettingRepository.Update(func(setting *entity.Setting) error {
+		setting.API.Enabled = in.Enabled
+		setting.API.Port = in.Port
+		if in.RegenerateToken || (in.Enabled && setting.API.Token == "") {
+			b := make([]byte, apiTokenBytes)
+			if _, err := rand.Read(b); err != nil {
+				return fmt.Errorf("generate api token: %w", err)
+			}
+			setting.API.Token = hex.EncodeToString(b)
+		}
+		saved = setting.API
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+)
+
+const (
+	minCaptureSize = 16
+	maxCaptureSize = 4096
+	// minCaptureFPS 未満だとフレーム間隔がカメラの停止検知（5 秒）を超えてしまうため、0（無制限）以外はこれ以上に限る。
+	minCaptureFPS = 1
+	maxCaptureFPS = 120
+)
+
+type UpdateCaptureSettingInput struct {
+	Width    int // 0 ならソースの解像度のまま（Height も 0 にする）
+	Height   int
+	MaxFPS   float64 // 0 なら制限しない。それ以外は 1 以上
+	Rotation int     // 0 / 90 / 180 / 270
+	Mirror   bool
+}
+
+type UpdateCaptureSettingInputPort interface {
+	Execute(ctx context.Context, in *UpdateCaptureSettingInput) error
+}
+
+type UpdateCaptureSettingInteractor struct {
+	SettingRepository repository.SettingRepository
+}
+
+func NewUpdateCaptureSettingUsecase(settingRepository repository.SettingRepository) UpdateCaptureSettingInputPort {
+	return &UpdateCaptureSettingInteractor{
+		SettingRepository: settingRepository,
+	}
+}
+
+func (i *UpdateCaptureSettingInteractor) Execute(ctx context.Context, in *UpdateCaptureSettingInput) error {
+	if (in.Width == 0) != (in.Height == 0) {
+		return fmt.Errorf("width and height must both be 0 or both be set (width=%d, height=%d)", in.Width, in.Height)
+	}
+	if in.Width != 0 && (in.Width < minCaptureSize || in.Width > maxCaptureSize || in.Height < minCaptureSize || in.Height > maxCaptureSize) {
+		return fmt.Errorf("resolution must be within %d..%d, got %dx%d", minCaptureSize, maxCaptureSize, in.Width, in.Height)
+	}
+	if in.MaxFPS != 0 && (in.MaxFPS < minCaptureFPS || in.MaxFPS > maxCaptureFPS) {
+		return fmt.Errorf("maxFPS must be 0 or within %d..%d, got %g", minCaptureFPS, maxCaptureFPS, in.MaxFPS)
+	}
+	if !entity.ValidRotation(in.Rotation) {
+		return fmt.Errorf("rotation must be 0, 90, 180 or 270, got %d", in.Rotation)
+	}
+	return i.SettingRepository.Update(func(setting *entity.Setting) error {
+		setting.CaptureWidth = in.Width
+		setting.CaptureHeight = in.Height
+		setting.MaxFPS = in.MaxFPS
+		setting.Rotation = in.Rotation
+		setting.Mirror = in.Mirror
+		return nil
+	})
+}
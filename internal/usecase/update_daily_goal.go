@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+const maxDailyGoal = 10000
+
+type UpdateDailyGoalInputPort interface {
+	Execute(ctx context.Context, goal int) error
+}
+
+type UpdateDailyGoalInteractor struct {
+	SettingRepository repository.SettingRepository
+}
+
+func NewUpdateDailyGoalUsecase(settingRepository repository.SettingRepository) UpdateDailyGoalInputPort {
+	return &UpdateDailyGoalInteractor{
+		SettingRepository: settingRepository,
+	}
+}
+
+// Execute は 1 日の目標回数を保存する。0 なら目標なし。
+func (i *UpdateDailyGoalInteractor) Execute(ctx context.Context, goal int) error {
+	if goal < 0 || goal > maxDailyGoal {
+		return errors.ErrInvalidArgument.Errorf("goal must be in [0, %d], got %d", maxDailyGoal, goal)
+	}
+	return i.SettingRepository.Update(func(setting *entity.Setting) error {
+		setting.DailyGoal = goal
+		return nil
+	})
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
+)
+
+type UpdateMQTTSettingInput struct {
+	Enabled  bool
+	Broker   string
+	Username string
+	// Password は nil なら保存済みのパスワードをそのまま使う（GetSetting はパスワードを返さないため）。空文字列なら消す
+	Password        *string
+	TopicPrefix     string
+	DiscoveryPrefix string
+}
+
+type UpdateMQTTSettingInputPort interface {
+	Execute(ctx context.Context, in *UpdateMQTTSettingInput) (*entity.MQTTSetting, error)
+}
+
+type UpdateMQTTSettingInteractor struct {
+	SettingRepository repository.SettingRepository
+}
+
+func NewUpdateMQTTSettingUsecase(settingRepository repository.SettingRepository) UpdateMQTTSettingInputPort {
+	return &UpdateMQTTSettingInteractor{
+		SettingRepository: settingRepository,
+	}
+}
+
+// Execute は MQTT の設定を検証して保存し、保存後の設定（パスワードを含む）を返す。トピックの接頭辞が空なら既定値にする。
+func (i *UpdateMQTTSettingInteractor) Execute(ctx context.Context, in *UpdateMQTTSettingInput) (*entity.MQTTSetting, error) {
+	var conf entity.MQTTSetting
+	err := i.SettingRepository.Update(func(setting *entity.Setting) error {
+		conf = entity.MQTTSetting{
+			Enabled:         in.Enabled,
+			Broker:          strings.TrimSpace(in.Broker),
+			Username:        in.Username,
+			Password:        setting.MQTT.Password,
+			TopicPrefix:     in.TopicPrefix,
+			DiscoveryPrefix: in.DiscoveryPrefix,
+		}
+		if in.Password != nil {
+			conf.Password = *in.Password
+		}
+		if conf.TopicPrefix == "" {
+			conf.TopicPrefix = entity.DefaultMQTTTopicPrefix
+		}
+		if err := conf.Validate(); err != nil {
+			return errors.ErrInvalidArgument.Errorf("mqtt: %v", err)
+		}
+		setting.MQTT = conf
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
+	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+)
+
+// minROISize は ROI の幅・高さの下限（フレームに対する比率）。小さすぎると顔が収まらない。
+const minROISize = 0.1
+
+type UpdateROIInputPort interface {
+	Execute(ctx context.Context, roi entity.Region) error
+}
+
+type UpdateROIInteractor struct {
+	SettingRepository repository.SettingRepository
+}
+
+func NewUpdateROIUsecase(settingRepository repository.SettingRepository) UpdateROIInputPort {
+	return &UpdateROIInteractor{
+		SettingRepository: settingRepository,
+	}
+}
+
+// Execute は顔検出の対象にする範囲を保存する。幅か高さが 0 ならフレーム全体に戻す。
+func (i *UpdateROIInteractor) Execute(ctx context.Context, roi entity.Region) error {
+	if roi.IsFull() {
+		roi = entity.Region{}
+	} else {
+		if !roi.Valid() {
+			return fmt.Errorf("roi must be within the frame (0..1), got %+v", roi)
+		}
+		if roi.Width < minROISize || roi.Height < minROISize {
+			return fmt.Errorf("roi must be at least %.0f%% of the frame, got %.2fx%.2f", minROISize*100, roi.Width, roi.Height)
+		}
+	}
+	return i.SettingRepository.Update(func(setting *entity.Setting) error {
+		setting.ROI = roi
+		return nil
+	})
+}
@@ -2,10 +2,10 @@ package usecase
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/kikils/desk-squat-tracker/internal/domain/entity"
 	"github.com/kikils/desk-squat-tracker/internal/domain/repository"
+	"github.com/kikils/desk-squat-tracker/internal/errors"
 )
 
 type UpdateSettingInputPort interface {
@@ -24,16 +24,18 @@ func NewUpdateSettingUsecase(settingRepository repository.SettingRepository) Upd
 
 func (i *UpdateSettingInteractor) Execute(ctx context.Context, topRatio, bottomRatio float64) error {
 	if topRatio <= 0 || topRatio >= 1 {
-		return fmt.Errorf("topRatio must be in (0, 1), got %f", topRatio)
+		return errors.ErrInvalidArgument.Errorf("topRatio must be in (0, 1), got %f", topRatio)
 	}
 	if bottomRatio <= 0 || bottomRatio >= 1 {
-		return fmt.Errorf("bottomRatio must be in (0, 1), got %f", bottomRatio)
+		return errors.ErrInvalidArgument.Errorf("bottomRatio must be in (0, 1), got %f", bottomRatio)
 	}
 	if bottomRatio >= topRatio {
-		return fmt.Errorf("bottomRatio must be less than topRatio (bottomRatio=%f, topRatio=%f)", bottomRatio, topRatio)
+		return errors.ErrInvalidArgument.Errorf("bottomRatio must be less than topRatio (bottomRatio=%f, topRatio=%f)", bottomRatio, topRatio)
 	}
-	return i.SettingRepository.Save(&entity.Setting{
-		TopRatio:    topRatio,
-		BottomRatio: bottomRatio,
+	// 判定しきい値以外の設定（選択中のカメラなど）はそのまま残す
+	return i.SettingRepository.Update(func(setting *entity.Setting) error {
+		setting.TopRatio = topRatio
+		setting.BottomRatio = bottomRatio
+		return nil
 	})
 }
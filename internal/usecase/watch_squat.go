@@ -17,8 +17,25 @@ type WatchSquatOutput struct {
 	Judgement *entity.Judgement
 }
 
+// WatchSquatInput は Execute の入力。
+type WatchSquatInput struct {
+	Frame     []byte    // 検出に渡す JPEG（Crop があれば切り抜いた後の画像）
+	Timestamp time.Time // フレームの撮影時刻。顔と判定の時刻になる
+	// Crop は Frame が元フレームのどの範囲を切り抜いたものか。nil なら元フレーム全体。
+	// 指定した場合、検出した顔は FrameWidth×FrameHeight の元フレーム上の座標に戻して判定する。
+	Crop        *entity.Rect
+	FrameWidth  int
+	FrameHeight int
+}
+
 type WatchSquatInputPort interface {
-	Execute(ctx context.Context, frame []byte, t time.Time) (*WatchSquatOutput, error)
+	// Execute は Detect と Judge を続けて行う。
+	Execute(ctx context.Context, in *WatchSquatInput) (*WatchSquatOutput, error)
+	// Detect は顔検出だけを行い、元フレーム上の座標の顔を返す。顔がなければ nil。
+	// 判定の状態には触れないため、Judge とは別のゴルーチンから呼んでよい。
+	Detect(ctx context.Context, in *WatchSquatInput) (*entity.Face, error)
+	// Judge は Detect の結果を判定して保存する。face が nil なら何もしない。フレームの時刻順に呼ぶこと。
+	Judge(face *entity.Face) (*WatchSquatOutput, error)
 }
 
 type WatchSquatInteractor struct {
@@ -35,15 +52,33 @@ func NewWatchSquatUsecase(faceRepository repository.FaceRepository, judgementRep
 	}
 }
 
-func (i *WatchSquatInteractor) Execute(ctx context.Context, frame []byte, t time.Time) (*WatchSquatOutput, error) {
-	face, err := i.FaceRepository.Detect(ctx, frame, t)
+func (i *WatchSquatInteractor) Execute(ctx context.Context, in *WatchSquatInput) (*WatchSquatOutput, error) {
+	face, err := i.Detect(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return i.Judge(face)
+}
+
+func (i *WatchSquatInteractor) Detect(ctx context.Context, in *WatchSquatInput) (*entity.Face, error) {
+	face, err := i.FaceRepository.Detect(ctx, in.Frame, in.Timestamp)
 	if err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
-			return &WatchSquatOutput{}, nil
+			return nil, nil
 		}
 		return nil, err
 	}
+	if in.Crop != nil {
+		// 判定しきい値やオーバーレイは元フレームに対する比率なので、座標を元フレームに戻す
+		face = face.FromCrop(*in.Crop, in.FrameWidth, in.FrameHeight)
+	}
+	return face, nil
+}
 
+func (i *WatchSquatInteractor) Judge(face *entity.Face) (*WatchSquatOutput, error) {
+	if face == nil {
+		return &WatchSquatOutput{}, nil
+	}
 	judgement, err := i.SquatJudger.Judge(face)
 	if err != nil {
 		return nil, err
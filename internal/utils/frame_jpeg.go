@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"image"
 	"image/jpeg"
+	"sync"
 )
 
 // Frame is a single frame from the camera (packed YCbCr444).
@@ -14,38 +15,62 @@ type Frame struct {
 	Height int
 }
 
-// EncodeJPEG converts a YCbCr444-packed frame to JPEG bytes.
-// Quality is 1-100; typical value 70-85.
-func EncodeJPEG(f Frame, quality int) ([]byte, error) {
+// JPEGEncoder encodes packed YCbCr444 frames to JPEG, reusing its planes,
+// image.YCbCr header and output buffer between calls. Encoding frames of the
+// same size repeatedly allocates nothing for the planes or the output.
+// A JPEGEncoder must not be used concurrently.
+type JPEGEncoder struct {
+	img image.YCbCr
+	buf bytes.Buffer
+}
+
+// Encode converts f to JPEG bytes. The returned slice is owned by the encoder
+// and is only valid until the next call to Encode; copy it to keep it longer.
+func (e *JPEGEncoder) Encode(f Frame, quality int) ([]byte, error) {
 	if len(f.Data) != f.Width*f.Height*3 || f.Width <= 0 || f.Height <= 0 {
 		return nil, nil
 	}
-	img := packedYCbCr444ToImage(f)
-	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+	e.unpack(f)
+	e.buf.Reset()
+	if err := jpeg.Encode(&e.buf, &e.img, &jpeg.Options{Quality: quality}); err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	return e.buf.Bytes(), nil
 }
 
-// packedYCbCr444ToImage converts packed YCbCr444 (Y,Cb,Cr per pixel) to image.YCbCr.
-func packedYCbCr444ToImage(f Frame) *image.YCbCr {
+// unpack splits packed YCbCr444 (Y,Cb,Cr per pixel) into the encoder's planes.
+func (e *JPEGEncoder) unpack(f Frame) {
 	n := f.Width * f.Height
-	y := make([]byte, n)
-	cb := make([]byte, n)
-	cr := make([]byte, n)
+	if cap(e.img.Y) < n {
+		e.img.Y = make([]byte, n)
+		e.img.Cb = make([]byte, n)
+		e.img.Cr = make([]byte, n)
+	}
+	y, cb, cr := e.img.Y[:n], e.img.Cb[:n], e.img.Cr[:n]
 	for i := 0; i < n; i++ {
 		y[i] = f.Data[i*3]
 		cb[i] = f.Data[i*3+1]
 		cr[i] = f.Data[i*3+2]
 	}
-	return &image.YCbCr{
-		Y:              y,
-		Cb:             cb,
-		Cr:             cr,
-		YStride:        f.Width,
-		CStride:        f.Width,
-		SubsampleRatio: image.YCbCrSubsampleRatio444,
-		Rect:           image.Rect(0, 0, f.Width, f.Height),
+	e.img.Y, e.img.Cb, e.img.Cr = y, cb, cr
+	e.img.YStride = f.Width
+	e.img.CStride = f.Width
+	e.img.SubsampleRatio = image.YCbCrSubsampleRatio444
+	e.img.Rect = image.Rect(0, 0, f.Width, f.Height)
+}
+
+var encoderPool = sync.Pool{New: func() any { return new(JPEGEncoder) }}
+
+// EncodeJPEG converts a YCbCr444-packed frame to JPEG bytes.
+// Quality is 1-100; typical value 70-85.
+// It borrows a pooled JPEGEncoder and returns a copy the caller may keep;
+// hot paths that encode every frame should hold their own JPEGEncoder instead.
+func EncodeJPEG(f Frame, quality int) ([]byte, error) {
+	e := encoderPool.Get().(*JPEGEncoder)
+	defer encoderPool.Put(e)
+	b, err := e.Encode(f, quality)
+	if err != nil || b == nil {
+		return nil, err
 	}
+	return bytes.Clone(b), nil
 }
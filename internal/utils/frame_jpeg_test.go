@@ -0,0 +1,43 @@
+package utils
+
+import "testing"
+
+// benchFrame は 640x480 のグラデーション（JPEG の圧縮が極端にならない程度の模様）のフレーム。
+func benchFrame() Frame {
+	const w, h = 640, 480
+	data := make([]byte, w*h*3)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := (y*w + x) * 3
+			data[i] = byte(x ^ y)
+			data[i+1] = byte(x / 3)
+			data[i+2] = byte(y / 2)
+		}
+	}
+	return Frame{Data: data, Width: w, Height: h}
+}
+
+// BenchmarkJPEGEncoder_Encode はキャプチャのように 1 つのエンコーダーで毎フレームをエンコードする。
+func BenchmarkJPEGEncoder_Encode(b *testing.B) {
+	f := benchFrame()
+	var e JPEGEncoder
+	b.ReportAllocs()
+	b.SetBytes(int64(len(f.Data)))
+	for b.Loop() {
+		if _, err := e.Encode(f, 75); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncodeJPEG はプールのエンコーダーを借りて、結果をコピーして返す。
+func BenchmarkEncodeJPEG(b *testing.B) {
+	f := benchFrame()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(f.Data)))
+	for b.Loop() {
+		if _, err := EncodeJPEG(f, 75); err != nil {
+			b.Fatal(err)
+		}
+	}
+}